@@ -0,0 +1,294 @@
+package gorelease
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/ulikunitz/xz"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// supported archive formats
+const (
+	FormatTarGz = "tar.gz"
+	FormatTarXz = "tar.xz"
+	FormatZip   = "zip"
+)
+
+// DefaultArchiveName is a default template of archive name
+const DefaultArchiveName = "{{.Target}}_{{.Version}}_{{.Os}}_{{.Arch}}"
+
+// Archive configures packaging of FileBuilds into archives
+type Archive struct {
+	Format  string            `yaml:"format"`  // default format, tar.gz if empty
+	Formats map[string]string `yaml:"formats"` // format per GOOS, zip for windows if empty
+	Name    string            `yaml:"name"`    // template of archive name without extension
+	Files   []string          `yaml:"files"`   // globs of extra files like LICENSE or README*
+}
+
+var ErrorUnknownArchiveFormat = errors.New("unknown archive format")
+var ErrorArchiveFileName = errors.New("archive file name is outside of archive")
+
+// FormatFor returns archive format for goos
+func (a *Archive) FormatFor(goos string) string {
+	if f, ok := a.Formats[goos]; ok {
+		return f
+	}
+	if goos == "windows" {
+		return FormatZip
+	}
+	if a.Format == "" {
+		return FormatTarGz
+	}
+	return a.Format
+}
+
+// ArchiveError is returned when archive can't be created
+type ArchiveError struct {
+	Path string // path of archive
+	Err  error
+}
+
+func (e *ArchiveError) Error() string {
+	return fmt.Sprintf("while creating archive %s: %s", e.Path, e.Err)
+}
+
+func (e *ArchiveError) Unwrap() error {
+	return e.Err
+}
+
+// ArchiveRelease is a basic BuildReleaseFunc packaging
+// FileBuilds of targets with archive config
+var ArchiveRelease BuildReleaseFunc = func(release *Release) error {
+	return ArchiveReleaseContext(context.Background(), release)
+}
+
+// ArchiveReleaseContext is a basic BuildReleaseContextFunc packaging
+// FileBuilds of targets with archive config
+var ArchiveReleaseContext BuildReleaseContextFunc = func(ctx context.Context, release *Release) error {
+	return release.ForEachTargetBuild(func(t *Target, b *FileBuild) error {
+		if b.ArchivePath == "" {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		log.Printf("archiving: %s %s - %s", b.GOOS, b.GOARCH, b.ArchivePath)
+		if err := MakeArchive(b, t.Archive.Files, archiveTime(t.Meta)); err != nil {
+			return &ArchiveError{Path: b.ArchivePath, Err: err}
+		}
+		return nil
+	})
+}
+
+// archivePath returns path of archive for FileBuild of expanded Target
+func archivePath(t Target, b FileBuild) (string, error) {
+	if t.Archive == nil {
+		return "", nil
+	}
+	format := t.Archive.FormatFor(b.GOOS)
+	switch format {
+	case FormatTarGz, FormatTarXz, FormatZip:
+	default:
+		return "", errors.Wrap(ErrorUnknownArchiveFormat, format)
+	}
+	tmpl := t.Archive.Name
+	if tmpl == "" {
+		tmpl = DefaultArchiveName
+	}
+	name, err := ExecTemplate("archive name", tmpl, MakeTemplateData(t, b.GOOS, b.GOARCH))
+	if err != nil {
+		return "", err
+	}
+	return path.Join(path.Dir(b.BinPath), name+"."+format), nil
+}
+
+// archiveTime returns modification time of archived files,
+// commit date is used so archives are reproducible
+func archiveTime(m Meta) time.Time {
+	if m.CommitDate.IsZero() {
+		// earliest time zip can store
+		return time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return m.CommitDate
+}
+
+// archiveFile is a file stored in archive
+type archiveFile struct {
+	src  string // path on disk
+	name string // path in archive
+	mode os.FileMode
+}
+
+// archiveFiles returns binary and files matching globs sorted by name
+func archiveFiles(b *FileBuild, globs []string) ([]archiveFile, error) {
+	files := []archiveFile{{src: b.BinPath, name: path.Base(b.BinPath), mode: 0755}}
+	var extra []archiveFile
+	for _, g := range globs {
+		matches, err := filepath.Glob(g)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			log.Printf("archive: no files match %s", g)
+		}
+		base := globBase(g)
+		for _, m := range matches {
+			err = filepath.Walk(m, func(p string, info os.FileInfo, err error) error {
+				if err != nil || info.IsDir() {
+					return err
+				}
+				name, err := filepath.Rel(base, p)
+				if err != nil {
+					return err
+				}
+				name = path.Clean(filepath.ToSlash(name))
+				if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+					return errors.Wrap(ErrorArchiveFileName, name)
+				}
+				extra = append(extra, archiveFile{src: p, name: name, mode: 0644})
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	sort.Slice(extra, func(i, j int) bool { return extra[i].name < extra[j].name })
+	return append(files, extra...), nil
+}
+
+// globBase returns dir of glob without pattern characters,
+// files are stored in archive by path relative to it
+func globBase(glob string) string {
+	dir := filepath.Dir(glob)
+	for strings.ContainsAny(dir, "*?[") && dir != filepath.Dir(dir) {
+		dir = filepath.Dir(dir)
+	}
+	return dir
+}
+
+// MakeArchive writes ArchivePath of FileBuild with built binary and extra files,
+// all files have modification time mtime and normalized permissions
+func MakeArchive(b *FileBuild, globs []string, mtime time.Time) error {
+	files, err := archiveFiles(b, globs)
+	if err != nil {
+		return err
+	}
+
+	// write to temporary file so partial archive is never left behind
+	tmp, err := ioutil.TempFile(path.Dir(b.ArchivePath), ".archive")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	switch format := archiveFormat(b.ArchivePath); format {
+	case FormatZip:
+		err = writeZip(tmp, files, mtime)
+	case FormatTarGz:
+		var gz *gzip.Writer
+		gz, err = gzip.NewWriterLevel(tmp, gzip.BestCompression)
+		if err == nil {
+			gz.ModTime = mtime
+			err = closeAfter(gz, writeTar(gz, files, mtime))
+		}
+	case FormatTarXz:
+		var x *xz.Writer
+		x, err = xz.NewWriter(tmp)
+		if err == nil {
+			err = closeAfter(x, writeTar(x, files, mtime))
+		}
+	default:
+		err = errors.Wrap(ErrorUnknownArchiveFormat, format)
+	}
+	if err = closeAfter(tmp, err); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), b.ArchivePath)
+}
+
+func archiveFormat(p string) string {
+	for _, f := range []string{FormatTarGz, FormatTarXz, FormatZip} {
+		if len(p) > len(f) && p[len(p)-len(f)-1:] == "."+f {
+			return f
+		}
+	}
+	return path.Ext(p)
+}
+
+func writeTar(w io.Writer, files []archiveFile, mtime time.Time) error {
+	tw := tar.NewWriter(w)
+	for _, f := range files {
+		info, err := os.Stat(f.src)
+		if err != nil {
+			return err
+		}
+		hdr := &tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     f.name,
+			Size:     info.Size(),
+			Mode:     int64(f.mode),
+			ModTime:  mtime,
+		}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if err = copyFile(tw, f.src); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func writeZip(w io.Writer, files []archiveFile, mtime time.Time) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		hdr := &zip.FileHeader{
+			Name:     f.name,
+			Method:   zip.Deflate,
+			Modified: mtime,
+		}
+		hdr.SetMode(f.mode)
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		if err = copyFile(fw, f.src); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func copyFile(w io.Writer, src string) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// closeAfter closes c and returns first error
+func closeAfter(c io.Closer, err error) error {
+	if cerr := c.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
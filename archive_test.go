@@ -0,0 +1,154 @@
+package gorelease_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func archiveTarget(t *testing.T, format string) Target {
+	dir, err := ioutil.TempDir("", "gorelease")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Target{
+		FilePath: "main.go",
+		Name:     "app",
+		Version:  "v1.0.0",
+		DestDir:  dir,
+		Archive:  &Archive{Format: format, Files: []string{"main.go", "*.yaml"}},
+		Meta:     Meta{CommitDate: time.Date(2020, 9, 14, 0, 0, 0, 0, time.UTC)},
+	}
+}
+
+func makeArchive(t *testing.T, target Target, goos string) FileBuild {
+	b, err := MakeFileBuild(target, goos, "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(b.BinPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(b.BinPath, []byte("binary"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err = MakeArchive(&b, target.Archive.Files, target.Meta.CommitDate); err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestMakeArchive_tarGz(t *testing.T) {
+	target := archiveTarget(t, FormatTarGz)
+	defer os.RemoveAll(target.DestDir)
+
+	b := makeArchive(t, target, "linux")
+	if want := filepath.Join(target.DestDir, "v1.0.0/linux_amd64/app_v1.0.0_linux_amd64.tar.gz"); b.ArchivePath != want {
+		t.Fatalf("got: %v want: %v", b.ArchivePath, want)
+	}
+	first, err := ioutil.ReadFile(b.ArchivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(first))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			break
+		}
+		names = append(names, hdr.Name)
+		if !hdr.ModTime.Equal(target.Meta.CommitDate) {
+			t.Errorf("%s got mtime: %v", hdr.Name, hdr.ModTime)
+		}
+		if hdr.Name == "app" && hdr.Mode != 0755 {
+			t.Errorf("got mode: %o want: 755", hdr.Mode)
+		}
+	}
+	if len(names) != 3 || names[0] != "app" || names[1] != ".gorelease.yaml" || names[2] != "main.go" {
+		t.Errorf("got files: %v", names)
+	}
+
+	// archive is reproducible
+	makeArchive(t, target, "linux")
+	second, err := ioutil.ReadFile(b.ArchivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("archives differ")
+	}
+}
+
+func TestMakeArchive_windowsZip(t *testing.T) {
+	target := archiveTarget(t, FormatTarXz)
+	defer os.RemoveAll(target.DestDir)
+
+	b := makeArchive(t, target, "windows")
+	if filepath.Ext(b.ArchivePath) != ".zip" {
+		t.Fatalf("got: %v want zip", b.ArchivePath)
+	}
+	zr, err := zip.OpenReader(b.ArchivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	if len(zr.File) != 3 || zr.File[0].Name != "app.exe" {
+		t.Errorf("got files: %v", zr.File)
+	}
+	if b.ReleasePath() != b.ArchivePath {
+		t.Errorf("got: %v want: %v", b.ReleasePath(), b.ArchivePath)
+	}
+}
+
+func TestMakeArchive_tarXz(t *testing.T) {
+	target := archiveTarget(t, FormatTarXz)
+	defer os.RemoveAll(target.DestDir)
+
+	b := makeArchive(t, target, "darwin")
+	if filepath.Base(b.ArchivePath) != "app_v1.0.0_darwin_amd64.tar.xz" {
+		t.Errorf("got: %v", b.ArchivePath)
+	}
+}
+
+func TestMakeArchive_globBase(t *testing.T) {
+	target := archiveTarget(t, FormatZip)
+	defer os.RemoveAll(target.DestDir)
+
+	// files outside of working dir are stored relative to dir of glob
+	extra := filepath.Join(target.DestDir, "extra")
+	if err := os.MkdirAll(filepath.Join(extra, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"LICENSE", "docs/app.1"} {
+		if err := ioutil.WriteFile(filepath.Join(extra, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	target.Archive.Files = []string{filepath.Join(extra, "LICENSE"), filepath.Join(extra, "d*")}
+	b := makeArchive(t, target, "windows")
+	zr, err := zip.OpenReader(b.ArchivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer zr.Close()
+	var names []string
+	for _, f := range zr.File {
+		names = append(names, f.Name)
+	}
+	if len(names) != 3 || names[1] != "LICENSE" || names[2] != "docs/app.1" {
+		t.Errorf("got files: %v", names)
+	}
+}
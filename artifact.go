@@ -0,0 +1,92 @@
+package gorelease
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ArtifactType describes what Artifact is
+type ArtifactType string
+
+const (
+	ArtifactBinary    ArtifactType = "binary"
+	ArtifactArchive   ArtifactType = "archive"
+	ArtifactPackage   ArtifactType = "package"
+	ArtifactChecksum  ArtifactType = "checksum"
+	ArtifactSignature ArtifactType = "signature"
+	ArtifactManifest  ArtifactType = "manifest"
+)
+
+// Artifact is a file published by release
+type Artifact struct {
+	Path   string       // local path of file
+	Type   ArtifactType // kind of file
+	Target string       // name of target, empty for release wide files
+	Build  *FileBuild   // build file was made from, nil for release wide files
+}
+
+// Name returns path of artifact relative to release dir
+func (a Artifact) Name(r *Release) string {
+	if rel, err := filepath.Rel(r.Dir, a.Path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path.Base(filepath.ToSlash(a.Path))
+}
+
+// FlatName returns name of artifact unique in Release without directories,
+// for backends keeping files of release in single flat list,
+// base name is used if it's unique or path with slashes replaced otherwise
+func (a Artifact) FlatName(r *Release) string {
+	base := path.Base(filepath.ToSlash(a.Path))
+	for _, other := range r.Artifacts() {
+		if other.Path != a.Path && path.Base(filepath.ToSlash(other.Path)) == base {
+			return strings.Replace(a.Name(r), "/", "_", -1)
+		}
+	}
+	return base
+}
+
+// ExpandedVersion returns version of prepared Release with templates executed
+func (r *Release) ExpandedVersion() (string, error) {
+	expanded, err := expandTarget(r.Target, "", "")
+	if err != nil {
+		return "", err
+	}
+	return expanded.Version, nil
+}
+
+// Artifacts returns every file of prepared Release that is published,
+// paths are deterministic so release may run in other process than build
+func (r *Release) Artifacts() []Artifact {
+	var artifacts []Artifact
+	for i := range r.Targets {
+		t := &r.Targets[i]
+		for j := range t.FileBuilds {
+			b := &t.FileBuilds[j]
+			typ := ArtifactBinary
+			if b.ArchivePath != "" {
+				typ = ArtifactArchive
+			}
+			artifacts = append(artifacts, Artifact{Path: b.ReleasePath(), Type: typ, Target: t.Name, Build: b})
+			for _, p := range b.PackagePaths {
+				artifacts = append(artifacts, Artifact{Path: p, Type: ArtifactPackage, Target: t.Name, Build: b})
+			}
+		}
+	}
+	artifacts = append(artifacts, Artifact{Path: r.ChecksumPath(), Type: ArtifactChecksum})
+	artifacts = append(artifacts, r.signatureArtifacts(signedArtifacts(artifacts))...)
+	// manifest is written after every other artifact is published
+	artifacts = append(artifacts, Artifact{Path: r.ManifestPath(), Type: ArtifactManifest})
+	return artifacts
+}
+
+// ForEachArtifact performs func f for each Artifact of Release
+func (r *Release) ForEachArtifact(f func(a *Artifact) error) error {
+	for _, a := range r.Artifacts() {
+		if err := f(&a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
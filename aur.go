@@ -0,0 +1,221 @@
+package gorelease
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"strings"
+)
+
+// DefaultAURMessage is a default template of commit message of PKGBUILD
+const DefaultAURMessage = "{{.Target}} {{.Version}}"
+
+// AURArch maps GOARCH to architecture of arch linux package,
+// arm builds are expected to be built with GOARM=7
+var AURArch = []struct{ goarch, arch string }{
+	{"amd64", "x86_64"},
+	{"arm64", "aarch64"},
+	{"arm", "armv7h"},
+}
+
+// AUR configures PKGBUILD and .SRCINFO of -bin package rendered
+// from release manifest with linux builds of one target
+type AUR struct {
+	Target      string        `yaml:"target"`      // name of target, first target if empty
+	Name        string        `yaml:"name"`        // pkgname, name of target with -bin suffix if empty
+	Description string        `yaml:"description"` // pkgdesc
+	Homepage    string        `yaml:"homepage"`    // url
+	License     []string      `yaml:"license"`     //
+	Maintainers []string      `yaml:"maintainers"` // like Name <email>
+	Release     int           `yaml:"release"`     // pkgrel, 1 if empty
+	Depends     []string      `yaml:"depends"`     //
+	OptDepends  []string      `yaml:"optdepends"`  // like git: for git support
+	Provides    []string      `yaml:"provides"`    // name of target if empty
+	Conflicts   []string      `yaml:"conflicts"`   // name of target if empty
+	Package     string        `yaml:"package"`     // bash of package function, installs executable if empty
+	Repository  GitRepository `yaml:"repository"`  // AUR checkout, files are written into release dir if dir is empty
+}
+
+var ErrorAURNoBuilds = errors.New("target has no linux artifacts")
+
+// AURRelease is a basic ReleaseFunc writing PKGBUILD and .SRCINFO
+var AURRelease ReleaseFunc = func(release *Release) error {
+	return AURReleaseContext(context.Background(), release)
+}
+
+// AURReleaseContext is a basic ReleaseContextFunc writing PKGBUILD and .SRCINFO
+// from manifest of published release, they're committed into AUR checkout
+// if it's configured or written into release dir otherwise
+var AURReleaseContext ReleaseContextFunc = func(ctx context.Context, release *Release) error {
+	a := release.AUR
+	if a == nil {
+		return nil
+	}
+	m, err := releaseManifest(release)
+	if err != nil {
+		return err
+	}
+	pkgbuild, srcinfo, err := MakePKGBUILD(release, m)
+	if err != nil {
+		return err
+	}
+	if a.Repository.Dir == "" {
+		if err = writeReleaseFile(release, "PKGBUILD", pkgbuild); err != nil {
+			return err
+		}
+		return writeReleaseFile(release, ".SRCINFO", srcinfo)
+	}
+	message, err := commitMessage(release, a.PackageName(release), a.Repository.Message, DefaultAURMessage)
+	if err != nil {
+		return err
+	}
+	return a.Repository.CommitFiles(ctx, map[string][]byte{"PKGBUILD": pkgbuild, ".SRCINFO": srcinfo}, message)
+}
+
+// PackageName returns pkgname of package
+func (a *AUR) PackageName(r *Release) string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return targetName(r, a.Target) + "-bin"
+}
+
+// aurSource is source of architecture with its checksum
+type aurSource struct {
+	arch, file, url, sha256 string
+}
+
+// MakePKGBUILD renders PKGBUILD and matching .SRCINFO with per architecture
+// sources of archives, or executables if builds are not archived, from Manifest
+func MakePKGBUILD(r *Release, m *Manifest) (pkgbuild, srcinfo []byte, err error) {
+	a := r.AUR
+	target, err := r.TargetNamed(a.Target)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := a.PackageName(r)
+	// pkgver can't contain hyphen, vercmp sorts 1.2.0rc.1 before 1.2.0
+	version := strings.Replace(strings.TrimPrefix(m.Version, "v"), "-", "", -1)
+	release := a.Release
+	if release == 0 {
+		release = 1
+	}
+	provides, conflicts := a.Provides, a.Conflicts
+	if provides == nil {
+		provides = []string{target.Name}
+	}
+	if conflicts == nil {
+		conflicts = []string{target.Name}
+	}
+
+	var sources []aurSource
+	var binaries bool
+	for _, arch := range AURArch {
+		artifact := m.Artifact(target.Name, "linux", arch.goarch)
+		if artifact == nil {
+			continue
+		}
+		if artifact.URL == "" {
+			return nil, nil, errors.Errorf("artifact %s has no url", artifact.Name)
+		}
+		// sources are renamed so files of versions and architectures differ
+		file := fmt.Sprintf("%s-%s-%s", name, version, arch.arch)
+		if artifact.Type == ArtifactBinary {
+			binaries = true
+		} else {
+			file += "." + archiveFormat(artifact.Name)
+		}
+		sources = append(sources, aurSource{arch.arch, file, artifact.URL, artifact.SHA256})
+	}
+	if len(sources) == 0 {
+		return nil, nil, ErrorAURNoBuilds
+	}
+
+	install := a.Package
+	if install == "" {
+		src := "./" + target.Name
+		if binaries {
+			src = `./${pkgname}-${pkgver}-${CARCH}`
+		}
+		install = fmt.Sprintf(`install -Dm755 "%s" "${pkgdir}/usr/bin/%s"`, src, target.Name)
+	}
+
+	var b strings.Builder
+	for _, maintainer := range a.Maintainers {
+		fmt.Fprintf(&b, "# Maintainer: %s\n", maintainer)
+	}
+	b.WriteString("# This file was generated by gorelease. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&b, "pkgname=%s\npkgver=%s\npkgrel=%d\n", bashString(name), bashString(version), release)
+	if a.Description != "" {
+		fmt.Fprintf(&b, "pkgdesc=%s\n", bashString(a.Description))
+	}
+	var archs []string
+	for _, s := range sources {
+		archs = append(archs, s.arch)
+	}
+	fmt.Fprintf(&b, "arch=%s\n", bashArray(archs))
+	if a.Homepage != "" {
+		fmt.Fprintf(&b, "url=%s\n", bashString(a.Homepage))
+	}
+	for _, f := range []struct {
+		name   string
+		values []string
+	}{
+		{"license", a.License},
+		{"depends", a.Depends},
+		{"optdepends", a.OptDepends},
+		{"provides", provides},
+		{"conflicts", conflicts},
+	} {
+		if len(f.values) > 0 {
+			fmt.Fprintf(&b, "%s=%s\n", f.name, bashArray(f.values))
+		}
+	}
+	for _, s := range sources {
+		fmt.Fprintf(&b, "\nsource_%s=%s\nsha256sums_%s=%s\n",
+			s.arch, bashArray([]string{s.file + "::" + s.url}), s.arch, bashArray([]string{s.sha256}))
+	}
+	fmt.Fprintf(&b, "\npackage() {\n%s}\n", indent(install, "  "))
+	pkgbuild = []byte(b.String())
+
+	// .SRCINFO like makepkg --printsrcinfo
+	b.Reset()
+	fmt.Fprintf(&b, "pkgbase = %s\n", name)
+	field := func(key string, values ...string) {
+		for _, v := range values {
+			if v != "" {
+				fmt.Fprintf(&b, "\t%s = %s\n", key, v)
+			}
+		}
+	}
+	field("pkgdesc", a.Description)
+	field("pkgver", version)
+	field("pkgrel", fmt.Sprint(release))
+	field("url", a.Homepage)
+	field("arch", archs...)
+	field("license", a.License...)
+	field("depends", a.Depends...)
+	field("optdepends", a.OptDepends...)
+	field("provides", provides...)
+	field("conflicts", conflicts...)
+	for _, s := range sources {
+		field("source_"+s.arch, s.file+"::"+s.url)
+		field("sha256sums_"+s.arch, s.sha256)
+	}
+	fmt.Fprintf(&b, "\npkgname = %s\n", name)
+	return pkgbuild, []byte(b.String()), nil
+}
+
+// bashString returns single quoted bash string
+func bashString(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// bashArray returns bash array of single quoted strings
+func bashArray(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = bashString(v)
+	}
+	return "(" + strings.Join(quoted, " ") + ")"
+}
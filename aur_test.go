@@ -0,0 +1,128 @@
+package gorelease_test
+
+import (
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMakePKGBUILD(t *testing.T) {
+	r := manifestRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	r.Manifest.Version = "v1.2.0-rc.1"
+	r.AUR = &AUR{
+		Description: "App's tool",
+		Homepage:    "https://example.com",
+		License:     []string{"MIT"},
+		Maintainers: []string{"Release Bot <bot@example.com>"},
+		Depends:     []string{"glibc"},
+	}
+	pkgbuild, srcinfo, err := MakePKGBUILD(r, r.Manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# Maintainer: Release Bot <bot@example.com>
+# This file was generated by gorelease. DO NOT EDIT.
+
+pkgname='app-bin'
+pkgver='1.2.0rc.1'
+pkgrel=1
+pkgdesc='App'\''s tool'
+arch=('x86_64' 'aarch64')
+url='https://example.com'
+license=('MIT')
+depends=('glibc')
+provides=('app')
+conflicts=('app')
+
+source_x86_64=('app-bin-1.2.0rc.1-x86_64.tar.gz::https://example.com/v1.2.0/linux_amd64/app_v1.2.0_linux_amd64.tar.gz')
+sha256sums_x86_64=('aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa')
+
+source_aarch64=('app-bin-1.2.0rc.1-aarch64.tar.gz::https://example.com/v1.2.0/linux_arm64/app_v1.2.0_linux_arm64.tar.gz')
+sha256sums_aarch64=('aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa')
+
+package() {
+  install -Dm755 "./app" "${pkgdir}/usr/bin/app"
+}
+`
+	if string(pkgbuild) != want {
+		t.Errorf("got:\n%s", pkgbuild)
+	}
+	want = `pkgbase = app-bin
+	pkgdesc = App's tool
+	pkgver = 1.2.0rc.1
+	pkgrel = 1
+	url = https://example.com
+	arch = x86_64
+	arch = aarch64
+	license = MIT
+	depends = glibc
+	provides = app
+	conflicts = app
+	source_x86_64 = app-bin-1.2.0rc.1-x86_64.tar.gz::https://example.com/v1.2.0/linux_amd64/app_v1.2.0_linux_amd64.tar.gz
+	sha256sums_x86_64 = aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+	source_aarch64 = app-bin-1.2.0rc.1-aarch64.tar.gz::https://example.com/v1.2.0/linux_arm64/app_v1.2.0_linux_arm64.tar.gz
+	sha256sums_aarch64 = aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa
+
+pkgname = app-bin
+`
+	if string(srcinfo) != want {
+		t.Errorf("got:\n%s", srcinfo)
+	}
+
+	// executable of arm build is installed by its source name
+	r.Manifest.Artifacts = append(r.Manifest.Artifacts, ManifestArtifact{
+		Name: "linux_arm/app", Type: ArtifactBinary, Target: "app", Os: "linux", Arch: "arm",
+		URL: "https://example.com/v1.2.0/linux_arm/app", SHA256: strings.Repeat("b", 64),
+	})
+	pkgbuild, srcinfo, err = MakePKGBUILD(r, r.Manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"arch=('x86_64' 'aarch64' 'armv7h')\n",
+		"source_armv7h=('app-bin-1.2.0rc.1-armv7h::https://example.com/v1.2.0/linux_arm/app')\n",
+		`install -Dm755 "./${pkgname}-${pkgver}-${CARCH}" "${pkgdir}/usr/bin/app"`,
+	} {
+		if !strings.Contains(string(pkgbuild), s) {
+			t.Errorf("no %s in:\n%s", s, pkgbuild)
+		}
+	}
+	if !strings.Contains(string(srcinfo), "\tsha256sums_armv7h = "+strings.Repeat("b", 64)+"\n") {
+		t.Errorf("got:\n%s", srcinfo)
+	}
+}
+
+func TestAURRelease(t *testing.T) {
+	r := manifestRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	r.AUR = &AUR{}
+	if err := AURRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"PKGBUILD", ".SRCINFO"} {
+		if _, err := ioutil.ReadFile(filepath.Join(r.Dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	checkout, bare := tapRepository(t, r.DestDir)
+	r.AUR.Repository = GitRepository{Dir: checkout, Remote: "origin", Branch: "master", Author: "Release Bot <bot@example.com>"}
+	if err := AURRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	if log := git(t, bare, "log", "--format=%s", "master"); log != "app-bin v1.2.0\n" {
+		t.Errorf("got: %q", log)
+	}
+	if files := git(t, bare, "ls-tree", "--name-only", "master"); files != ".SRCINFO\nPKGBUILD\n" {
+		t.Errorf("got: %q", files)
+	}
+
+	r.Manifest.Artifacts = r.Manifest.Artifacts[:2]
+	if err := AURRelease(r); err != ErrorAURNoBuilds {
+		t.Errorf("got: %v", err)
+	}
+}
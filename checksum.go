@@ -0,0 +1,230 @@
+package gorelease
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"hash"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// supported checksum algorithms
+const (
+	ChecksumSHA256 = "sha256"
+	ChecksumSHA512 = "sha512"
+)
+
+// DefaultChecksumName is a default name of checksums file
+const DefaultChecksumName = "checksums.txt"
+
+// Checksum configures checksums file of release
+type Checksum struct {
+	Algorithm string `yaml:"algorithm"` // sha256 or sha512, sha256 if empty
+	Name      string `yaml:"name"`      // name of checksums file
+}
+
+var ErrorUnknownChecksumAlgorithm = errors.New("unknown checksum algorithm")
+
+// NewHash returns hash of checksum algorithm
+func NewHash(algorithm string) (hash.Hash, error) {
+	switch algorithm {
+	case ChecksumSHA256, "":
+		return sha256.New(), nil
+	case ChecksumSHA512:
+		return sha512.New(), nil
+	}
+	return nil, errors.Wrap(ErrorUnknownChecksumAlgorithm, algorithm)
+}
+
+// ChecksumPath returns path of checksums file of prepared Release
+func (r *Release) ChecksumPath() string {
+	name := r.Checksum.Name
+	if name == "" {
+		name = DefaultChecksumName
+	}
+	return path.Join(r.Dir, name)
+}
+
+// FileChecksum returns hex encoded checksum of file
+func FileChecksum(algorithm, file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return readerChecksum(algorithm, f)
+}
+
+func readerChecksum(algorithm string, r io.Reader) (string, error) {
+	h, err := NewHash(algorithm)
+	if err != nil {
+		return "", err
+	}
+	if _, err = io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ChecksumRelease is a basic BuildReleaseFunc writing checksums file
+var ChecksumRelease BuildReleaseFunc = func(release *Release) error {
+	return ChecksumReleaseContext(context.Background(), release)
+}
+
+// ChecksumReleaseContext is a basic BuildReleaseContextFunc writing checksums file,
+// it has one line in format of sha256sum for every built artifact sorted by name,
+// names are relative to release dir like linux_amd64/app_v1.0.0_linux_amd64.tar.gz
+var ChecksumReleaseContext BuildReleaseContextFunc = func(ctx context.Context, release *Release) error {
+	sums := map[string]string{}
+	var names []string
+	for _, a := range release.Artifacts() {
+		if a.Type != ArtifactBinary && a.Type != ArtifactArchive && a.Type != ArtifactPackage {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		name := a.Name(release)
+		sum, err := FileChecksum(release.Checksum.Algorithm, a.Path)
+		if err != nil {
+			return &ChecksumError{Name: name, Err: err}
+		}
+		sums[name] = sum
+		names = append(names, name)
+	}
+	// order of builds differs between runs
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s  %s\n", sums[name], name)
+	}
+	file := release.ChecksumPath()
+	log.Printf("writing checksums: %s", file)
+	return ioutil.WriteFile(file, []byte(b.String()), 0644)
+}
+
+// ChecksumError is returned when checksum of file can't be verified
+type ChecksumError struct {
+	Name string // name of file from checksums file
+	Want string
+	Got  string
+	Err  error
+}
+
+func (e *ChecksumError) Error() string {
+	if e.Err != nil {
+		return fmt.Sprintf("checksum of %s: %s", e.Name, e.Err)
+	}
+	return fmt.Sprintf("checksum of %s: got %s want %s", e.Name, e.Got, e.Want)
+}
+
+func (e *ChecksumError) Unwrap() error {
+	return e.Err
+}
+
+// ChecksumErrors holds every file that failed verification
+type ChecksumErrors []*ChecksumError
+
+func (e ChecksumErrors) Error() string {
+	var msgs []string
+	for _, err := range e {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf("%d file(s) failed verification:\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+// OpenFunc opens file with name relative to checksums file
+type OpenFunc func(ctx context.Context, name string) (io.ReadCloser, error)
+
+// DirOpener returns OpenFunc opening files from local directory
+func DirOpener(dir string) OpenFunc {
+	return func(ctx context.Context, name string) (io.ReadCloser, error) {
+		return os.Open(filepath.Join(dir, filepath.FromSlash(name)))
+	}
+}
+
+// ReadChecksums reads checksums file name and returns checksums by file name
+func ReadChecksums(ctx context.Context, name string, open OpenFunc) (names []string, sums map[string]string, err error) {
+	rc, err := open(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rc.Close()
+
+	sums = make(map[string]string)
+	scanner := bufio.NewScanner(rc)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, nil, errors.Errorf("malformed checksums line: '%s'", line)
+		}
+		file := strings.TrimPrefix(fields[1], "*")
+		names = append(names, file)
+		sums[file] = fields[0]
+	}
+	return names, sums, scanner.Err()
+}
+
+// VerifyChecksums checks every file listed in checksums file name,
+// algorithm is detected from length of checksums
+func VerifyChecksums(ctx context.Context, name string, open OpenFunc) error {
+	names, sums, err := ReadChecksums(ctx, name, open)
+	if err != nil {
+		return err
+	}
+
+	var errs ChecksumErrors
+	for _, file := range names {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := verifyChecksum(ctx, file, sums[file], open); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	log.Printf("verified %d file(s) from %s", len(names), name)
+	return nil
+}
+
+func verifyChecksum(ctx context.Context, name, want string, open OpenFunc) *ChecksumError {
+	var algorithm string
+	switch len(want) {
+	case sha256.Size * 2:
+		algorithm = ChecksumSHA256
+	case sha512.Size * 2:
+		algorithm = ChecksumSHA512
+	default:
+		return &ChecksumError{Name: name, Want: want, Err: ErrorUnknownChecksumAlgorithm}
+	}
+	rc, err := open(ctx, name)
+	if err != nil {
+		return &ChecksumError{Name: name, Want: want, Err: err}
+	}
+	defer rc.Close()
+	got, err := readerChecksum(algorithm, rc)
+	if err != nil {
+		return &ChecksumError{Name: name, Want: want, Err: err}
+	}
+	if got != want {
+		return &ChecksumError{Name: name, Want: want, Got: got}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package gorelease_test
+
+import (
+	"context"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func checksumRelease(t *testing.T, algorithm string) *Release {
+	target := archiveTarget(t, FormatTarGz)
+	target.Archive = nil
+	r := &Release{Target: target, Checksum: Checksum{Algorithm: algorithm}}
+	r.Dir = filepath.Join(target.DestDir, target.Version)
+	for _, goos := range []string{"linux", "windows"} {
+		b, err := MakeFileBuild(target, goos, "amd64")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = os.MkdirAll(filepath.Dir(b.BinPath), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err = ioutil.WriteFile(b.BinPath, []byte(goos), 0755); err != nil {
+			t.Fatal(err)
+		}
+		target.FileBuilds = append(target.FileBuilds, b)
+	}
+	r.Targets = []Target{target}
+	return r
+}
+
+func TestChecksumRelease(t *testing.T) {
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	// lines are sorted by name, not by order of builds
+	builds := r.Targets[0].FileBuilds
+	builds[0], builds[1] = builds[1], builds[0]
+
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(r.Dir, DefaultChecksumName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// printf linux | sha256sum
+	want := "caf90169eefa5f807d577486b9f795ab86ae2983c5c20806cff959117e90af18  linux_amd64/app\n"
+	lines := strings.SplitAfter(string(b), "\n")
+	if len(lines) != 3 || lines[0] != want || !strings.HasSuffix(lines[1], "  windows_amd64/app.exe\n") {
+		t.Errorf("got: %q want first line: %q", b, want)
+	}
+
+	ctx := context.Background()
+	if err = VerifyChecksums(ctx, DefaultChecksumName, DirOpener(r.Dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	// tamper with file
+	if err = ioutil.WriteFile(r.Targets[0].FileBuilds[0].BinPath, []byte("tampered"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	err = VerifyChecksums(ctx, DefaultChecksumName, DirOpener(r.Dir))
+	errs, ok := err.(ChecksumErrors)
+	if !ok || len(errs) != 1 || errs[0].Name != "windows_amd64/app.exe" {
+		t.Errorf("got: %v", err)
+	}
+}
+
+func TestChecksumRelease_sha512(t *testing.T) {
+	r := checksumRelease(t, ChecksumSHA512)
+	defer os.RemoveAll(r.DestDir)
+	r.Checksum.Name = "SHA512SUMS"
+
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyChecksums(context.Background(), "SHA512SUMS", DirOpener(r.Dir)); err != nil {
+		t.Fatal(err)
+	}
+}
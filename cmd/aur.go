@@ -0,0 +1,16 @@
+package cmd
+
+import . "github.com/bukowa/gorelease"
+
+var aurGenerator = generator{
+	name:       "aur",
+	short:      "write PKGBUILD and .SRCINFO of published release",
+	long:       "render PKGBUILD and .SRCINFO of -bin package from manifest of published release and commit them into AUR checkout, or write them into release dir if repository is not set",
+	repoFlag:   "repository",
+	repoUsage:  "local checkout of AUR package",
+	configured: func(r *Release) bool { return r.AUR != nil },
+	repository: func(r *Release) *GitRepository { return &r.AUR.Repository },
+	release:    AURReleaseContext,
+}
+
+var AURCmd = aurGenerator.command()
@@ -3,28 +3,64 @@ package cmd
 import (
 	"github.com/bukforks/cobra"
 	. "github.com/bukowa/gorelease"
-	"log"
 )
 
+var Jobs int
+
 var BuildCmd = &cobra.Command{
 	Use:     "build",
 	Short:   "go build targets",
 	Version: Version,
-	Run: func(cmd *cobra.Command, args []string) {
-		release := FromFile(Path)
+	RunE: func(cmd *cobra.Command, args []string) error {
+		release, err := FromFile(Path)
+		if err != nil {
+			return err
+		}
+
+		ctx, cancel := releaseContext(cmd, release)
+		defer cancel()
 
 		// prepare
-		if err := Prepare(release); err != nil {
-			log.Fatal(err)
+		if err := PrepareContext(ctx, release); err != nil {
+			return err
+		}
+
+		// override jobs from config
+		if cmd.Flags().Changed("jobs") {
+			release.Jobs = Jobs
 		}
 
 		// build
-		if err := BuildRelease(release); err != nil {
-			log.Fatal(err)
+		if err := BuildReleaseContext(ctx, release); err != nil {
+			return err
+		}
+
+		// archive
+		if err := ArchiveReleaseContext(ctx, release); err != nil {
+			return err
 		}
+
+		// packages
+		if err := PackageReleaseContext(ctx, release); err != nil {
+			return err
+		}
+
+		// checksums
+		if err := ChecksumReleaseContext(ctx, release); err != nil {
+			return err
+		}
+
+		// signatures
+		if err := SignReleaseContext(ctx, release); err != nil {
+			return err
+		}
+
+		// image
+		return ImageReleaseContext(ctx, release)
 	},
 }
 
 func init() {
 	BuildCmd.Flags().StringVarP(&Path, "config", "c", ".gorelease.yaml", "path go gorelease config file")
+	BuildCmd.Flags().IntVarP(&Jobs, "jobs", "j", 0, "number of concurrent builds (default number of CPUs)")
 }
@@ -0,0 +1,54 @@
+package cmd
+
+import (
+	"context"
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+var (
+	Timeout        time.Duration
+	ReleaseVersion string
+)
+
+// releaseContext returns context that is cancelled on SIGINT or SIGTERM
+// or when release timeout passes
+func releaseContext(cmd *cobra.Command, release *Release) (context.Context, context.CancelFunc) {
+	// override timeout from config
+	if cmd.Flags().Changed("timeout") {
+		release.Timeout = Timeout
+	}
+	// override version from config
+	if cmd.Flags().Changed("release-version") {
+		release.Version = ReleaseVersion
+	}
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if release.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(context.Background(), release.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(context.Background())
+	}
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case s := <-sig:
+			log.Printf("received %s, cancelling", s)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(sig)
+		cancel()
+	}
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"github.com/pkg/errors"
+)
+
+// generator writes files from manifest of published release
+// and commits them into repository if it's configured
+type generator struct {
+	name       string // name of command and config section
+	short      string
+	long       string
+	repoFlag   string // flag overriding dir of repository
+	repoUsage  string
+	configured func(*Release) bool
+	repository func(*Release) *GitRepository
+	release    ReleaseContextFunc
+}
+
+// command returns command running generator with config file,
+// repository flags override repository from config
+func (g generator) command() *cobra.Command {
+	var repo GitRepository
+	cmd := &cobra.Command{
+		Use:     g.name,
+		Short:   g.short,
+		Long:    g.long,
+		Version: Version,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			release, err := FromFile(Path)
+			if err != nil {
+				return err
+			}
+			if !g.configured(release) {
+				return errors.Errorf("config has no %s section", g.name)
+			}
+			ctx, cancel := releaseContext(cmd, release)
+			defer cancel()
+
+			if err := PrepareContext(ctx, release); err != nil {
+				return err
+			}
+			if cmd.Flags().Changed(g.repoFlag) {
+				g.repository(release).Dir = repo.Dir
+			}
+			if cmd.Flags().Changed("remote") {
+				g.repository(release).Remote = repo.Remote
+			}
+			return g.release(ctx, release)
+		},
+	}
+	f := cmd.Flags()
+	f.StringVarP(&Path, "config", "c", ".gorelease.yaml", "path go gorelease config file")
+	f.StringVar(&repo.Dir, g.repoFlag, "", fmt.Sprintf("%s, overrides %s dir from config", g.repoUsage, g.repoFlag))
+	f.StringVar(&repo.Remote, "remote", "", fmt.Sprintf("remote pushed after commit, overrides %s remote from config", g.repoFlag))
+	return cmd
+}
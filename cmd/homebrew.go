@@ -0,0 +1,16 @@
+package cmd
+
+import . "github.com/bukowa/gorelease"
+
+var homebrewGenerator = generator{
+	name:       "homebrew",
+	short:      "write homebrew formula of published release",
+	long:       "render homebrew formula from manifest of published release and commit it into tap, or write it into release dir if tap is not set",
+	repoFlag:   "tap",
+	repoUsage:  "local checkout of tap",
+	configured: func(r *Release) bool { return r.Homebrew != nil },
+	repository: func(r *Release) *GitRepository { return &r.Homebrew.Tap },
+	release:    HomebrewReleaseContext,
+}
+
+var HomebrewCmd = homebrewGenerator.command()
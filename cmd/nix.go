@@ -0,0 +1,16 @@
+package cmd
+
+import . "github.com/bukowa/gorelease"
+
+var nixGenerator = generator{
+	name:       "nix",
+	short:      "write nix expression of published release",
+	long:       "render default.nix, and flake.nix if enabled, fetching prebuilt artifacts from manifest of published release and commit them into repository, or write them into release dir if repository is not set",
+	repoFlag:   "repository",
+	repoUsage:  "local checkout of nix expression repository",
+	configured: func(r *Release) bool { return r.Nix != nil },
+	repository: func(r *Release) *GitRepository { return &r.Nix.Repository },
+	release:    NixReleaseContext,
+}
+
+var NixCmd = nixGenerator.command()
@@ -1,13 +1,108 @@
 package cmd
 
-import "github.com/bukforks/cobra"
+import (
+	"context"
+	"fmt"
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"github.com/pkg/errors"
+	"log"
+	"strings"
+)
 
 var ReleaseCmd = &cobra.Command{
 	Use:     "release",
 	Short:   "release your targets",
+	Long:    "release your targets with every publisher from release section of config",
 	Version: Version,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		release, err := FromFile(Path)
+		if err != nil {
+			return err
+		}
+		if len(release.Publishers) == 0 {
+			return errors.New("release section of config has no publishers")
+		}
+
+		// configure every publisher before anything is published
+		var publishers []Publisher
+		for _, c := range release.Publishers {
+			p, err := c.Publisher()
+			if err != nil {
+				return err
+			}
+			publishers = append(publishers, p)
+		}
+
+		ctx, cancel := releaseContext(cmd, release)
+		defer cancel()
+
+		if err := PrepareContext(ctx, release); err != nil {
+			return err
+		}
+		for _, p := range publishers {
+			if err := publish(ctx, release, p); err != nil {
+				return errors.Wrapf(err, "publisher %s", p.Name())
+			}
+		}
+
+		// files generated from manifest of last publisher,
+		// failure of one generator doesn't stop the others
+		var failed []string
+		for _, g := range []generator{
+			homebrewGenerator, scoopGenerator, wingetGenerator, aurGenerator, nixGenerator,
+		} {
+			if err := g.release(ctx, release); err != nil {
+				failed = append(failed, fmt.Sprintf("%s: %s", g.name, err))
+			}
+		}
+		if len(failed) > 0 {
+			return errors.Errorf("generators failed: %s", strings.Join(failed, "; "))
+		}
+		return nil
+	},
+}
+
+// publish publishes prepared release with p and logs urls
+func publish(ctx context.Context, release *Release, p Publisher) error {
+	var result = make(Result)
+	m, err := Publish(ctx, release, p, result)
+	if err != nil {
+		return err
+	}
+	for k, v := range result {
+		log.Print(k, " ", v)
+	}
+	log.Printf("manifest of %d artifact(s) written to %s", len(m.Artifacts), release.ManifestPath())
+	return nil
+}
+
+// releaseWith loads config and publishes release with p
+func releaseWith(cmd *cobra.Command, p Publisher) error {
+	release, err := FromFile(Path)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := releaseContext(cmd, release)
+	defer cancel()
+
+	if err := PrepareContext(ctx, release); err != nil {
+		return err
+	}
+	return publish(ctx, release, p)
 }
 
 func init() {
+	ReleaseCmd.PersistentFlags().StringVarP(&Path, "config", "c", ".gorelease.yaml", "path go gorelease config file")
 	ReleaseCmd.AddCommand(ReleaseGCS)
+	ReleaseCmd.AddCommand(ReleaseS3)
+	ReleaseCmd.AddCommand(ReleaseAzure)
+	ReleaseCmd.AddCommand(ReleaseLocal)
+	ReleaseCmd.AddCommand(ReleaseSFTP)
+	ReleaseCmd.AddCommand(ReleaseGitHub)
+	ReleaseCmd.AddCommand(ReleaseGitLab)
+	ReleaseCmd.AddCommand(ReleaseGitea)
+	ReleaseCmd.AddCommand(ReleaseHTTP)
+	ReleaseCmd.AddCommand(ReleaseOCI)
+	ReleaseCmd.AddCommand(ReleaseImage)
 }
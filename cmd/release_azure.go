@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"log"
+)
+
+var Azure AzurePublisher
+
+var ReleaseAzure = &cobra.Command{
+	Use:     "azure",
+	Short:   "release with azure blob storage",
+	Long:    "release with azure blob storage, credentials are read from AZURE_STORAGE_CONNECTION_STRING or AZURE_STORAGE_SAS_TOKEN",
+	Version: Version,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return releaseWith(cmd, &Azure)
+	},
+}
+
+func init() {
+	f := ReleaseAzure.Flags()
+	f.StringVar(&Azure.Container, "container", "", "container name")
+	f.StringVar(&Azure.Prefix, "prefix", "", "prefix of blob names")
+	f.StringVar(&Azure.Account, "account", "", "storage account used with sas token")
+	f.StringVar(&Azure.Endpoint, "endpoint", "", "blob endpoint like http://127.0.0.1:10000/devstoreaccount1")
+	f.Int64Var(&Azure.BlockSize, "block-size", DefaultAzureBlockSize, "size of uploaded blocks, smaller files are uploaded at once")
+	if err := ReleaseAzure.MarkFlagRequired("container"); err != nil {
+		log.Fatal(err)
+	}
+}
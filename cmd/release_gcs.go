@@ -12,18 +12,8 @@ var ReleaseGCS = &cobra.Command{
 	Use:     "gcs",
 	Short:   "release with google cloud storage",
 	Version: Version,
-	Run: func(cmd *cobra.Command, args []string) {
-		var result = make(GCSResult)
-		release := FromFile(Path)
-		if err := Prepare(release); err != nil {
-			log.Fatal(err)
-		}
-		if err := GCSRelease(Bucket, result)(release); err != nil {
-			log.Fatal(err)
-		}
-		for k, v := range result {
-			log.Print(k, " ", v)
-		}
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return releaseWith(cmd, &GCSPublisher{Bucket: Bucket})
 	},
 }
 
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"log"
+)
+
+var Gitea GiteaPublisher
+
+var ReleaseGitea = &cobra.Command{
+	Use:     "gitea",
+	Short:   "release with gitea releases",
+	Long:    "create or update gitea release of version tag and upload artifacts as its attachments, token is read from GITEA_TOKEN",
+	Version: Version,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return releaseWith(cmd, &Gitea)
+	},
+}
+
+func init() {
+	f := ReleaseGitea.Flags()
+	f.StringVar(&Gitea.URL, "url", "", "url of gitea instance")
+	f.StringVar(&Gitea.Owner, "owner", "", "owner of repository")
+	f.StringVar(&Gitea.Repo, "repo", "", "name of repository")
+	f.StringVar(&Gitea.Title, "title", "", "title of release (default tag)")
+	f.StringVar(&Gitea.Notes, "notes", "", "release notes")
+	f.StringVar(&Gitea.NotesFile, "notes-file", "", "path of release notes file")
+	f.BoolVar(&Gitea.Draft, "draft", false, "mark release as draft")
+	f.BoolVar(&Gitea.Prerelease, "prerelease", false, "mark release as prerelease")
+	for _, name := range []string{"url", "owner", "repo"} {
+		if err := ReleaseGitea.MarkFlagRequired(name); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"log"
+)
+
+var GitHub GitHubPublisher
+
+var ReleaseGitHub = &cobra.Command{
+	Use:     "github",
+	Short:   "release with github releases",
+	Long:    "create or update github release of version tag and upload artifacts as its assets, token is read from GITHUB_TOKEN",
+	Version: Version,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return releaseWith(cmd, &GitHub)
+	},
+}
+
+func init() {
+	f := ReleaseGitHub.Flags()
+	f.StringVar(&GitHub.Owner, "owner", "", "owner of repository")
+	f.StringVar(&GitHub.Repo, "repo", "", "name of repository")
+	f.StringVar(&GitHub.APIURL, "api-url", DefaultGitHubAPIURL, "url of api, like https://github.example.com/api/v3")
+	f.StringVar(&GitHub.Title, "title", "", "title of release (default tag)")
+	f.StringVar(&GitHub.Notes, "notes", "", "release notes")
+	f.StringVar(&GitHub.NotesFile, "notes-file", "", "path of release notes file")
+	f.BoolVar(&GitHub.Draft, "draft", false, "mark release as draft")
+	f.BoolVar(&GitHub.Prerelease, "prerelease", false, "mark release as prerelease")
+	for _, name := range []string{"owner", "repo"} {
+		if err := ReleaseGitHub.MarkFlagRequired(name); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"log"
+)
+
+var GitLab GitLabPublisher
+
+var ReleaseGitLab = &cobra.Command{
+	Use:     "gitlab",
+	Short:   "release with gitlab generic packages",
+	Long:    "upload artifacts to gitlab generic package registry and create or update release of version tag with links to them, token is read from GITLAB_TOKEN or CI_JOB_TOKEN",
+	Version: Version,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return releaseWith(cmd, &GitLab)
+	},
+}
+
+func init() {
+	f := ReleaseGitLab.Flags()
+	f.StringVar(&GitLab.URL, "url", DefaultGitLabURL, "url of gitlab instance")
+	f.StringVar(&GitLab.Project, "project", "", "id or path of project like group/project")
+	f.StringVar(&GitLab.Package, "package", "", "name of generic package (default base of project path)")
+	f.StringVar(&GitLab.Title, "title", "", "title of release (default tag)")
+	f.StringVar(&GitLab.Notes, "notes", "", "release notes")
+	f.StringVar(&GitLab.NotesFile, "notes-file", "", "path of release notes file")
+	if err := ReleaseGitLab.MarkFlagRequired("project"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"log"
+)
+
+var HTTP HTTPPublisher
+
+var ReleaseHTTP = &cobra.Command{
+	Use:     "http",
+	Short:   "release with http put",
+	Long:    "upload every artifact with http put to templated url, like artifactory, nexus raw repository or webdav, password is read from HTTP_PASSWORD or bearer token from HTTP_TOKEN",
+	Version: Version,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return releaseWith(cmd, &HTTP)
+	},
+}
+
+func init() {
+	f := ReleaseHTTP.Flags()
+	f.StringVar(&HTTP.URL, "url", "", "url template, "+DefaultHTTPPath+" is appended to url without templates")
+	f.StringVarP(&HTTP.Method, "method", "X", "PUT", "http method")
+	f.StringVarP(&HTTP.Username, "username", "u", "", "username of basic auth")
+	f.StringToStringVarP(&HTTP.Headers, "header", "H", nil, "extra headers like X-Name={{.Name}}")
+	f.BoolVar(&HTTP.ChecksumHeaders, "checksum-headers", false, "send X-Checksum-Sha256, X-Checksum-Sha1 and X-Checksum-Md5 headers")
+	f.BoolVar(&HTTP.MKCOL, "mkcol", false, "create webdav collections of templated path")
+	if err := ReleaseHTTP.MarkFlagRequired("url"); err != nil {
+		log.Fatal(err)
+	}
+}
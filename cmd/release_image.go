@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+)
+
+var ImageRepository string
+var ImageInsecure bool
+
+var ReleaseImage = &cobra.Command{
+	Use:     "image",
+	Short:   "push oci image to container registry",
+	Long:    "push image layout written by build to repository of image config, credentials are read from OCI_USERNAME and OCI_PASSWORD",
+	Version: Version,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		release, err := FromFile(Path)
+		if err != nil {
+			return err
+		}
+		ctx, cancel := releaseContext(cmd, release)
+		defer cancel()
+
+		if err := PrepareContext(ctx, release); err != nil {
+			return err
+		}
+		if release.Image == nil {
+			release.Image = &Image{}
+		}
+		if cmd.Flags().Changed("repository") {
+			release.Image.Repository = ImageRepository
+		}
+		if cmd.Flags().Changed("insecure") {
+			release.Image.Insecure = ImageInsecure
+		}
+		return PushImage(ctx, release)
+	},
+}
+
+func init() {
+	f := ReleaseImage.Flags()
+	f.StringVarP(&ImageRepository, "repository", "r", "", "repository like registry.example.com/team/app (default repository of image config)")
+	f.BoolVar(&ImageInsecure, "insecure", false, "use plain http")
+}
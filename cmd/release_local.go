@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"log"
+)
+
+var Local LocalPublisher
+
+var ReleaseLocal = &cobra.Command{
+	Use:     "local",
+	Short:   "release into local directory",
+	Long:    "release into local directory like file share, files are laid out like objects of gcs release",
+	Version: Version,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return releaseWith(cmd, &Local)
+	},
+}
+
+func init() {
+	ReleaseLocal.Flags().StringVarP(&Local.Dir, "dir", "d", "", "destination directory")
+	ReleaseLocal.Flags().BoolVarP(&Local.Force, "force", "f", false, "replace already published version")
+	if err := ReleaseLocal.MarkFlagRequired("dir"); err != nil {
+		log.Fatal(err)
+	}
+}
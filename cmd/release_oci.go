@@ -0,0 +1,29 @@
+package cmd
+
+import (
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"log"
+)
+
+var OCI OCIPublisher
+
+var ReleaseOCI = &cobra.Command{
+	Use:     "oci",
+	Short:   "release as oci artifacts to container registry",
+	Long:    "push artifacts to container registry like oras, with manifest per platform and image index tagged with version, credentials are read from OCI_USERNAME and OCI_PASSWORD",
+	Version: Version,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return releaseWith(cmd, &OCI)
+	},
+}
+
+func init() {
+	f := ReleaseOCI.Flags()
+	f.StringVarP(&OCI.Repository, "repository", "r", "", "repository like registry.example.com/team/app")
+	f.BoolVar(&OCI.Insecure, "insecure", false, "use plain http")
+	f.StringVar(&OCI.MediaType, "media-type", DefaultOCIArtifactType, "media type of file layers")
+	if err := ReleaseOCI.MarkFlagRequired("repository"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"log"
+)
+
+var S3 S3Publisher
+
+var ReleaseS3 = &cobra.Command{
+	Use:     "s3",
+	Short:   "release with s3 or s3 compatible storage",
+	Long:    "release with s3 or s3 compatible storage, credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY",
+	Version: Version,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return releaseWith(cmd, &S3)
+	},
+}
+
+func init() {
+	f := ReleaseS3.Flags()
+	f.StringVarP(&S3.Bucket, "bucket", "b", "", "bucket name")
+	f.StringVar(&S3.Prefix, "prefix", "", "prefix of object names")
+	f.StringVar(&S3.Region, "region", "", "region of bucket (default AWS_REGION or us-east-1)")
+	f.StringVar(&S3.Endpoint, "endpoint", "", "custom endpoint like http://localhost:9000")
+	f.BoolVar(&S3.PathStyle, "path-style", false, "use path style addressing")
+	f.StringVar(&S3.ACL, "acl", "", "canned acl of objects like public-read")
+	f.StringVar(&S3.StorageClass, "storage-class", "", "storage class of objects")
+	f.DurationVar(&S3.Presign, "presign", 0, "return presigned urls valid for duration")
+	if err := ReleaseS3.MarkFlagRequired("bucket"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"log"
+)
+
+var SFTP SFTPPublisher
+
+var ReleaseSFTP = &cobra.Command{
+	Use:     "sftp",
+	Short:   "release with sftp",
+	Long:    "release with sftp, files are uploaded into dir like objects of gcs release, host key is checked with known_hosts",
+	Version: Version,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return releaseWith(cmd, &SFTP)
+	},
+}
+
+func init() {
+	f := ReleaseSFTP.Flags()
+	f.StringVar(&SFTP.Host, "host", "", "ssh host")
+	f.IntVarP(&SFTP.Port, "port", "p", 22, "ssh port")
+	f.StringVarP(&SFTP.User, "user", "u", "", "ssh user (default $USER)")
+	f.StringVarP(&SFTP.Key, "key", "i", "", "path of private key, or key in SFTP_PRIVATE_KEY env")
+	f.BoolVar(&SFTP.Agent, "agent", false, "authenticate with ssh agent")
+	f.StringVar(&SFTP.KnownHosts, "known-hosts", "", "path of known_hosts file (default ~/.ssh/known_hosts)")
+	f.StringVarP(&SFTP.Dir, "dir", "d", "", "remote base directory")
+	f.StringVar(&SFTP.URL, "url", "", "base url of remote directory in result (default sftp:// url)")
+	for _, name := range []string{"host", "dir"} {
+		if err := ReleaseSFTP.MarkFlagRequired(name); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
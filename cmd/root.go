@@ -14,10 +14,20 @@ var RootCmd = &cobra.Command{
 	Use:     "gorelease",
 	Short:   "build and release your go application.",
 	Version: Version,
+	// errors are reported by cobra, main decides how to exit
+	SilenceUsage: true,
 }
 
 func init() {
+	RootCmd.PersistentFlags().DurationVar(&Timeout, "timeout", 0, "timeout of whole run, overrides timeout from config")
+	RootCmd.PersistentFlags().StringVar(&ReleaseVersion, "release-version", "", "version of release, overrides version from config, 'auto' resolves it from git tags")
 	RootCmd.AddCommand(BuildCmd)
 	RootCmd.AddCommand(ReleaseCmd)
+	RootCmd.AddCommand(VerifyCmd)
+	RootCmd.AddCommand(HomebrewCmd)
+	RootCmd.AddCommand(ScoopCmd)
+	RootCmd.AddCommand(WingetCmd)
+	RootCmd.AddCommand(AURCmd)
+	RootCmd.AddCommand(NixCmd)
 
 }
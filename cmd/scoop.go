@@ -0,0 +1,16 @@
+package cmd
+
+import . "github.com/bukowa/gorelease"
+
+var scoopGenerator = generator{
+	name:       "scoop",
+	short:      "write scoop manifest of published release",
+	long:       "render scoop manifest from manifest of published release and commit it into bucket, or write it into release dir if bucket is not set",
+	repoFlag:   "bucket",
+	repoUsage:  "local checkout of bucket",
+	configured: func(r *Release) bool { return r.Scoop != nil },
+	repository: func(r *Release) *GitRepository { return &r.Scoop.Bucket },
+	release:    ScoopReleaseContext,
+}
+
+var ScoopCmd = scoopGenerator.command()
@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"github.com/bukforks/cobra"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"path/filepath"
+)
+
+var (
+	ChecksumName string
+	PublicKey    string
+	GPGKeyring   string
+)
+
+var VerifyCmd = &cobra.Command{
+	Use:     "verify [dir]",
+	Short:   "verify release files against checksums file",
+	Long:    "verify release files in dir, in dir of release from config or in gcs bucket against checksums file",
+	Version: Version,
+	Args:    cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		var dir = ""
+		var name = ChecksumName
+
+		var release = &Release{}
+		if len(args) == 0 {
+			var err error
+			if release, err = FromFile(Path); err != nil {
+				return err
+			}
+		}
+
+		// version and timeout flags override config before it's prepared
+		ctx, cancel := releaseContext(cmd, release)
+		defer cancel()
+
+		if len(args) > 0 {
+			dir = args[0]
+		} else {
+			if err := PrepareContext(ctx, release); err != nil {
+				return err
+			}
+			dir, name = filepath.Split(release.ChecksumPath())
+			if cmd.Flags().Changed("checksums") {
+				name = ChecksumName
+			}
+		}
+
+		open := DirOpener(dir)
+		if Bucket != "" {
+			var err error
+			if open, err = GCSOpener(ctx, Bucket, dir); err != nil {
+				return err
+			}
+		}
+		if err := VerifyChecksums(ctx, name, open); err != nil {
+			return err
+		}
+		if GPGKeyring != "" {
+			b, err := ioutil.ReadFile(GPGKeyring)
+			if err != nil {
+				return err
+			}
+			if err = VerifyGPG(ctx, b, name, open); err != nil {
+				return err
+			}
+		}
+		if PublicKey == "" {
+			return nil
+		}
+		b, err := ioutil.ReadFile(PublicKey)
+		if err != nil {
+			return err
+		}
+		key, err := ParseMinisignPublicKey(b)
+		if err != nil {
+			return err
+		}
+		return VerifyMinisignRelease(ctx, key, name, open)
+	},
+}
+
+func init() {
+	VerifyCmd.Flags().StringVarP(&Path, "config", "c", ".gorelease.yaml", "path go gorelease config file")
+	VerifyCmd.Flags().StringVar(&ChecksumName, "checksums", DefaultChecksumName, "name of checksums file")
+	VerifyCmd.Flags().StringVarP(&Bucket, "bucket", "b", "", "verify objects in gcs bucket instead of local files")
+	VerifyCmd.Flags().StringVar(&PublicKey, "pubkey", "", "path of minisign public key, verifies signatures of files")
+	VerifyCmd.Flags().StringVar(&GPGKeyring, "gpg-keyring", "", "path of armored gpg public key ring, verifies signature of checksums file")
+}
@@ -0,0 +1,16 @@
+package cmd
+
+import . "github.com/bukowa/gorelease"
+
+var wingetGenerator = generator{
+	name:       "winget",
+	short:      "write winget manifests of published release",
+	long:       "render winget manifests from manifest of published release and commit them into repository, or write them into release dir if repository is not set",
+	repoFlag:   "repository",
+	repoUsage:  "local checkout of manifests repository",
+	configured: func(r *Release) bool { return r.Winget != nil },
+	repository: func(r *Release) *GitRepository { return &r.Winget.Repository },
+	release:    WingetReleaseContext,
+}
+
+var WingetCmd = wingetGenerator.command()
@@ -0,0 +1,96 @@
+package gorelease
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// GitRepository is local git checkout, like homebrew tap,
+// generated files are committed into
+type GitRepository struct {
+	Dir     string `yaml:"dir"`     // path of checkout
+	Remote  string `yaml:"remote"`  // remote pushed after commit, like origin, no push if empty
+	Branch  string `yaml:"branch"`  // pushed branch, current branch if empty
+	Author  string `yaml:"author"`  // like Name <email>, from git config if empty
+	Message string `yaml:"message"` // template of commit message
+}
+
+var authorRegexp = regexp.MustCompile(`^\s*(.*?)\s*<(.+)>\s*$`)
+
+// CommitFiles writes files with paths relative to Dir, commits them
+// if any changed and pushes commit to Remote
+func (g *GitRepository) CommitFiles(ctx context.Context, files map[string][]byte, message string) error {
+	var paths []string
+	for name, data := range files {
+		p := filepath.Join(g.Dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return err
+		}
+		log.Printf("writing %s", p)
+		if err := ioutil.WriteFile(p, data, 0644); err != nil {
+			return err
+		}
+		paths = append(paths, name)
+	}
+
+	if _, err := g.git(ctx, append([]string{"add", "--"}, paths...)...); err != nil {
+		return err
+	}
+	status, err := g.git(ctx, append([]string{"status", "--porcelain", "--"}, paths...)...)
+	if err != nil {
+		return err
+	}
+	if len(strings.TrimSpace(string(status))) == 0 {
+		log.Printf("nothing to commit in %s", g.Dir)
+		return nil
+	}
+	if _, err = g.git(ctx, append([]string{"commit", "-m", message, "--"}, paths...)...); err != nil {
+		return err
+	}
+	log.Printf("committed to %s: %s", g.Dir, message)
+
+	if g.Remote == "" {
+		return nil
+	}
+	ref := "HEAD"
+	if g.Branch != "" {
+		ref = "HEAD:" + g.Branch
+	}
+	log.Printf("pushing %s to %s", ref, g.Remote)
+	_, err = g.git(ctx, "push", g.Remote, ref)
+	return err
+}
+
+// git runs git command in Dir, as Author if it's set
+func (g *GitRepository) git(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = g.Dir
+	if m := authorRegexp.FindStringSubmatch(g.Author); m != nil {
+		cmd.Env = append(os.Environ(),
+			"GIT_AUTHOR_NAME="+m[1], "GIT_AUTHOR_EMAIL="+m[2],
+			"GIT_COMMITTER_NAME="+m[1], "GIT_COMMITTER_EMAIL="+m[2],
+		)
+	}
+	return runCmdErr(cmd)
+}
+
+// commitMessage executes template of commit message of generated file
+func commitMessage(r *Release, name, text, def string) (string, error) {
+	if text == "" {
+		text = def
+	}
+	version, err := r.ExpandedVersion()
+	if err != nil {
+		return "", err
+	}
+	data := MakeTemplateData(r.Target, "", "")
+	data.Version = version
+	data.Target = name
+	return ExecTemplate("commit message", text, data)
+}
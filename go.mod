@@ -7,6 +7,10 @@ require (
 	cloud.google.com/go/storage v1.11.0
 	github.com/bukforks/cobra v1.0.4
 	github.com/pkg/errors v0.9.1
+	github.com/pkg/sftp v1.12.0
+	github.com/ulikunitz/xz v0.5.8
+	golang.org/x/crypto v0.0.0-20201016220609-9e8e0b390897
+	golang.org/x/mod v0.3.0
 	golang.org/x/oauth2 v0.0.0-20200902213428-5d25da1a8d43
 	google.golang.org/api v0.31.0
 	gopkg.in/yaml.v2 v2.3.0
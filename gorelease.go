@@ -3,16 +3,16 @@ package gorelease
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"github.com/pkg/errors"
 	"gopkg.in/yaml.v2"
-	"io"
 	"io/ioutil"
-	"log"
 	"os"
 	"os/exec"
 	"path"
 	"strings"
+	"time"
 )
 
 type (
@@ -22,9 +22,32 @@ type (
 	ReleaseFunc      func(*Release) error
 )
 
+// context-aware variants of the function types
+type (
+	BuildContextFunc        func(context.Context, *Target) error
+	BuildReleaseContextFunc func(context.Context, *Release) error
+	PrepareContextFunc      func(context.Context, *Release) error
+	ReleaseContextFunc      func(context.Context, *Release) error
+)
+
 type Release struct {
-	Target  `yaml:",inline"`
-	Targets []Target `yaml:"targets"`
+	Target   `yaml:",inline"`
+	Targets  []Target      `yaml:"targets"`
+	Jobs     int           `yaml:"jobs"`     // number of concurrent builds
+	Timeout  time.Duration `yaml:"timeout"`  // timeout of whole release
+	Checksum Checksum      `yaml:"checksum"` // checksums file of release
+	Sign     Sign          `yaml:"sign"`     // signatures of artifacts
+	Image    *Image        `yaml:"image"`    // OCI image of linux builds
+	Homebrew *Homebrew     `yaml:"homebrew"` // formula of published release
+	Scoop    *Scoop        `yaml:"scoop"`    // scoop manifest of published release
+	Winget   *Winget       `yaml:"winget"`   // winget manifests of published release
+	AUR      *AUR          `yaml:"aur"`      // PKGBUILD of published release
+	Nix      *Nix          `yaml:"nix"`      // nix expression of published release
+
+	Publishers []PublisherConfig `yaml:"release"` // publishers of release artifacts
+
+	Dir      string    `yaml:"-"` // dir of release files, DestDir/Version
+	Manifest *Manifest `yaml:"-"` // manifest of published release
 }
 
 type Target struct {
@@ -37,6 +60,11 @@ type Target struct {
 	Flags     []string            `yaml:"flags"`     // flags passed to go build
 	Platforms map[string][]string `yaml:"platforms"` // for what platforms build
 
+	BuildTimeout time.Duration `yaml:"build_timeout"` // timeout of single go build
+	Archive      *Archive      `yaml:"archive"`       // package builds into archives
+	Package      *Package      `yaml:"package"`       // deb, rpm and apk packages of linux builds
+
+	Meta       Meta        `yaml:"-"`
 	FileBuilds []FileBuild `yaml:"-"`
 }
 
@@ -47,6 +75,19 @@ type FileBuild struct {
 	Args    []string // args passed to go build
 	GOOS    string
 	GOARCH  string
+	Timeout time.Duration // timeout of go build
+
+	ArchivePath  string   // path of archive with executable, empty if not archived
+	PackagePaths []string // paths of packages in order of package formats
+}
+
+// ReleasePath returns path of file that is released,
+// archive if FileBuild is archived or executable otherwise
+func (b *FileBuild) ReleasePath() string {
+	if b.ArchivePath != "" {
+		return b.ArchivePath
+	}
+	return b.BinPath
 }
 
 func (b *FileBuild) Command() *exec.Cmd {
@@ -55,6 +96,14 @@ func (b *FileBuild) Command() *exec.Cmd {
 	return cmd
 }
 
+// CommandContext is like Command but the process is killed
+// when ctx is done
+func (b *FileBuild) CommandContext(ctx context.Context) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "go", b.Args...)
+	cmd.Env = b.Env
+	return cmd
+}
+
 // ForEach performs func f for each Target in Release
 func (r *Release) ForEachTarget(f func(t *Target) error) error {
 	for _, target := range r.Targets {
@@ -77,9 +126,36 @@ func (r *Release) ForEachTargetBuild(f func(t *Target, f *FileBuild) error) erro
 	return nil
 }
 
-// MakeFileBuild creates FileBuild for Target
-func MakeFileBuild(t Target, goos, goarch string) FileBuild {
-	bin := path.Join(t.DestDir, t.Version, goos+"_"+goarch, t.Name)
+// TargetNamed returns target with name, first target if name is empty
+func (r *Release) TargetNamed(name string) (*Target, error) {
+	for i := range r.Targets {
+		if name == "" || r.Targets[i].Name == name {
+			return &r.Targets[i], nil
+		}
+	}
+	return nil, errors.Errorf("target %s not found", name)
+}
+
+// targetName returns name of target of release, name if there is no such target
+func targetName(r *Release, name string) string {
+	if t, err := r.TargetNamed(name); err == nil {
+		return t.Name
+	}
+	return name
+}
+
+// MakeFileBuild creates FileBuild for Target,
+// templates in Target fields are executed for given goos and goarch
+func MakeFileBuild(t Target, goos, goarch string) (FileBuild, error) {
+	t, err := expandTarget(t, goos, goarch)
+	if err != nil {
+		return FileBuild{}, err
+	}
+	name := t.Name
+	if goos == "windows" && path.Ext(name) != ".exe" {
+		name += ".exe"
+	}
+	bin := path.Join(t.DestDir, t.Version, goos+"_"+goarch, name)
 	flags := append(t.Flags, "-o", bin)
 	env := buildEnv(t, goos, goarch)
 	args := append(append([]string{"build"}, flags...), t.FilePath)
@@ -90,53 +166,92 @@ func MakeFileBuild(t Target, goos, goarch string) FileBuild {
 		Args:    args,
 		GOOS:    goos,
 		GOARCH:  goarch,
+		Timeout: t.BuildTimeout,
+	}
+	if b.ArchivePath, err = archivePath(t, b); err != nil {
+		return FileBuild{}, err
+	}
+	if b.PackagePaths, err = packagePaths(t, b); err != nil {
+		return FileBuild{}, err
 	}
-	return b
+	return b, nil
 }
 
 // Build is a basic BuildFunc
 var Build BuildFunc = func(target *Target) error {
-	for _, b := range target.FileBuilds {
-		cmd := b.Command()
-		log.Print(buildCmdLog(b, cmd))
-		out, _, err := runCmd(cmd)
-		if len(out) > 0 {
-			log.Print(string(out))
-		}
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return BuildContext(context.Background(), target)
 }
 
-var BuildRelease = func(release *Release) error {
-	for _, t := range release.Targets {
-		if err := Build(&t); err != nil {
-			return err
-		}
+// BuildContext is a basic BuildContextFunc
+var BuildContext BuildContextFunc = func(ctx context.Context, target *Target) error {
+	s := NewScheduler(0)
+	s.Add(target)
+	return s.Run(ctx)
+}
+
+// BuildRelease builds FileBuilds of all targets concurrently
+// with at most Release.Jobs builds running at once
+var BuildRelease BuildReleaseFunc = func(release *Release) error {
+	return BuildReleaseContext(context.Background(), release)
+}
+
+// BuildReleaseContext is a BuildRelease that stops
+// scheduling and kills running builds when ctx is done
+var BuildReleaseContext BuildReleaseContextFunc = func(ctx context.Context, release *Release) error {
+	s := NewScheduler(release.Jobs)
+	for i := range release.Targets {
+		s.Add(&release.Targets[i])
 	}
-	return nil
+	err := s.Run(ctx)
+	s.Report()
+	return err
 }
 
 // Prepare is a basic PrepareFunc
 var Prepare PrepareFunc = func(release *Release) error {
+	return PrepareContext(context.Background(), release)
+}
+
+// PrepareContext is a basic PrepareContextFunc
+var PrepareContext PrepareContextFunc = func(ctx context.Context, release *Release) error {
 
 	// check if version is set
 	if release.Version == "" {
 		return ErrorVersionNotSet
 	}
+	// resolve version from git tags
+	if release.Version == AutoVersion {
+		version, err := ResolveVersion(ctx, release.Version)
+		if err != nil {
+			return err
+		}
+		release.Version = version
+	}
 	// check if release is for all platforms
 	if isAllPlatforms(release.Platforms) {
-		release.Platforms = DistList()
+		dist, err := DistListContext(ctx)
+		if err != nil {
+			return err
+		}
+		release.Platforms = dist
 	}
+	// gather commit and date for templates
+	release.Meta = MakeMeta(ctx)
+
+	// release wide files are stored in DestDir/Version
+	expanded, err := expandTarget(release.Target, "", "")
+	if err != nil {
+		return err
+	}
+	release.Dir = path.Join(expanded.DestDir, expanded.Version)
+
 	// shorten var name
 	glob := release
 
 	// iterate over each target
 	for i, t := range release.Targets {
 
-		if t.Version == "" {
+		if t.Version == "" || t.Version == AutoVersion {
 			t.Version = glob.Version
 		}
 		if t.DestDir == "" {
@@ -154,8 +269,22 @@ var Prepare PrepareFunc = func(release *Release) error {
 		if t.Flags == nil {
 			t.Flags = glob.Flags
 		}
+		if t.BuildTimeout == 0 {
+			t.BuildTimeout = glob.BuildTimeout
+		}
+		if t.Archive == nil {
+			t.Archive = glob.Archive
+		}
+		if t.Package == nil {
+			t.Package = glob.Package
+		}
+		t.Meta = glob.Meta
 		if isAllPlatforms(t.Platforms) {
-			t.Platforms = DistList()
+			dist, err := DistListContext(ctx)
+			if err != nil {
+				return err
+			}
+			t.Platforms = dist
 		}
 		if t.Platforms == nil {
 			t.Platforms = glob.Platforms
@@ -164,7 +293,10 @@ var Prepare PrepareFunc = func(release *Release) error {
 		// make FileBuilds
 		for goos, goarchs := range t.Platforms {
 			for _, goarch := range goarchs {
-				build := MakeFileBuild(t, goos, goarch)
+				build, err := MakeFileBuild(t, goos, goarch)
+				if err != nil {
+					return err
+				}
 				t.FileBuilds = append(t.FileBuilds, build)
 			}
 		}
@@ -180,41 +312,85 @@ var Prepare PrepareFunc = func(release *Release) error {
 
 // DistList is a function that will gather all dists
 // by calling `go tool dist list`
-func DistList() map[string][]string {
-	cmd := exec.Command("go", "tool", "dist", "list")
-	output := runCmdFatal(cmd)
+func DistList() (map[string][]string, error) {
+	return DistListContext(context.Background())
+}
+
+// DistListContext is like DistList but
+// `go tool dist list` is killed when ctx is done
+func DistListContext(ctx context.Context) (map[string][]string, error) {
+	cmd := exec.CommandContext(ctx, "go", "tool", "dist", "list")
+	output, err := runCmdErr(cmd)
+	if err != nil {
+		return nil, err
+	}
 	var m = make(map[string][]string)
 	scanner := bufio.NewScanner(bytes.NewReader(output))
 	for scanner.Scan() {
 		s := strings.Split(scanner.Text(), "/")
+		if len(s) != 2 {
+			continue
+		}
 		m[s[0]] = append(m[s[0]], s[1])
 	}
-	return m
+	return m, nil
 }
 
 var ErrorBlankFileName = errors.New("target has blank file name")
 var ErrorVersionNotSet = errors.New("version is not set")
 var ErrorDuplicateNames = errors.New("release has targets with duplicate names")
+var ErrorEmptyConfig = errors.New("config is empty")
+
+// ConfigError is returned when config file can't be loaded
+type ConfigError struct {
+	Path string
+	Err  error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("while loading config '%s': %s", e.Path, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// CommandError is returned when executed command fails
+type CommandError struct {
+	Cmd    string // command line
+	Dir    string // working directory
+	Output []byte // combined stdout and stderr
+	Code   int    // exit code
+	Err    error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s - error while executing command: '%s'"+
+		" - in directory: '%s' - exit code: '%v' - error: '%s'",
+		e.Output, e.Cmd, e.Dir, e.Code, e.Err)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
 
 // FromFile creates Release from given path
-func FromFile(path string) *Release {
+func FromFile(path string) (*Release, error) {
 	return loadYaml(path)
 }
 
-func loadYaml(path string) (cfg *Release) {
-	f, err := os.Open(path)
+func loadYaml(path string) (cfg *Release, err error) {
+	b, err := ioutil.ReadFile(path)
 	if err != nil {
-		log.Fatal(err)
+		return nil, &ConfigError{Path: path, Err: err}
 	}
-	b, err := ioutil.ReadAll(f)
-	if err != nil {
-		log.Fatal(err)
+	if err = yaml.Unmarshal(b, &cfg); err != nil {
+		return nil, &ConfigError{Path: path, Err: err}
 	}
-	err = yaml.Unmarshal(b, &cfg)
-	if err != nil {
-		log.Fatal(errors.Wrap(err, path))
+	if cfg == nil {
+		return nil, &ConfigError{Path: path, Err: ErrorEmptyConfig}
 	}
-	return
+	return cfg, nil
 }
 
 func runCmd(cmd *exec.Cmd) (output []byte, code int, err error) {
@@ -224,17 +400,18 @@ func runCmd(cmd *exec.Cmd) (output []byte, code int, err error) {
 			code = v.ExitCode()
 		}
 	}
-	output = append(output, read(stdout)...)
-	output = append(output, read(stderr)...)
+	output = append(output, stdout.Bytes()...)
+	output = append(output, stderr.Bytes()...)
 	return
 }
 
-func runCmdFatal(cmd *exec.Cmd) []byte {
+// runCmdErr runs cmd and returns CommandError if it fails
+func runCmdErr(cmd *exec.Cmd) ([]byte, error) {
 	output, code, err := runCmd(cmd)
 	if err != nil || code != 0 {
-		log.Fatal(cmdErr(cmd, output, code, err))
+		return output, cmdErr(cmd, output, code, err)
 	}
-	return output
+	return output, nil
 }
 
 func cmdWd(cmd *exec.Cmd) string {
@@ -243,16 +420,19 @@ func cmdWd(cmd *exec.Cmd) string {
 	}
 	wd, err := os.Getwd()
 	if err != nil {
-		panic(err)
+		return ""
 	}
 	return wd
 }
 
 func cmdErr(cmd *exec.Cmd, output []byte, code int, err error) error {
-	return errors.Wrapf(err,
-		"%s - error while executing command: '%s'"+
-			" - in directory: '%s' - exit code: '%v' - error: '%s'",
-		output, cmd.String(), cmdWd(cmd), code, err)
+	return &CommandError{
+		Cmd:    cmd.String(),
+		Dir:    cmdWd(cmd),
+		Output: output,
+		Code:   code,
+		Err:    err,
+	}
 }
 
 func stdouts(cmd *exec.Cmd) (stdout, stderr *bytes.Buffer) {
@@ -263,14 +443,6 @@ func stdouts(cmd *exec.Cmd) (stdout, stderr *bytes.Buffer) {
 	return stdout, stderr
 }
 
-func read(r io.Reader) []byte {
-	b, err := ioutil.ReadAll(r)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return b
-}
-
 func isAllPlatforms(p map[string][]string) bool {
 	if _, ok := p["all"]; ok {
 		return true
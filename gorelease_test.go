@@ -1,6 +1,7 @@
 package gorelease_test
 
 import (
+	"errors"
 	"fmt"
 	. "github.com/bukowa/gorelease"
 	"log"
@@ -18,7 +19,10 @@ func TestMain(m *testing.M) {
 }
 
 func Test_goToolDistList(t *testing.T) {
-	dist := DistList()
+	dist, err := DistList()
+	if err != nil {
+		t.Fatal(err)
+	}
 	if len(dist) < 1 {
 		t.Error(dist)
 	}
@@ -82,7 +86,11 @@ func envMapInSlice(m map[string]string, env []string) bool {
 }
 
 func exampleRelease() *Release {
-	return FromFile(".gorelease.yaml")
+	r, err := FromFile(".gorelease.yaml")
+	if err != nil {
+		log.Fatal(err)
+	}
+	return r
 }
 
 func totalFiles(r *Release) (n int) {
@@ -93,3 +101,14 @@ func totalFiles(r *Release) (n int) {
 	}
 	return
 }
+
+func TestFromFile_error(t *testing.T) {
+	_, err := FromFile("missing.yaml")
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) {
+		t.Fatalf("want ConfigError got: %v", err)
+	}
+	if cfgErr.Path != "missing.yaml" || !os.IsNotExist(cfgErr.Err) {
+		t.Error(cfgErr)
+	}
+}
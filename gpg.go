@@ -0,0 +1,156 @@
+package gorelease
+
+import (
+	"bytes"
+	"context"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/packet"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// GPGExt is extension of ASCII-armored detached signatures
+const GPGExt = ".asc"
+
+// what is signed with gpg
+const (
+	GPGSignAll      = "all"      // every artifact and checksums file
+	GPGSignChecksum = "checksum" // checksums file only
+)
+
+// default env variables of gpg key
+const (
+	DefaultGPGKeyEnv        = "GPG_PRIVATE_KEY"
+	DefaultGPGPassphraseEnv = "GPG_PASSPHRASE"
+)
+
+// GPG configures OpenPGP detached signatures
+type GPG struct {
+	Key           string `yaml:"key"`            // path of armored private key
+	KeyEnv        string `yaml:"key_env"`        // env with armored private key
+	PassphraseEnv string `yaml:"passphrase_env"` // env with passphrase of private key
+	Artifacts     string `yaml:"artifacts"`      // all or checksum, all if empty
+}
+
+var (
+	ErrorGPGKeyNotSet = errors.New("gpg key is not set")
+	ErrorGPGNoSigner  = errors.New("gpg key ring has no private key")
+)
+
+func (g *GPG) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	type plain GPG
+	if err := unmarshal((*plain)(g)); err != nil {
+		return err
+	}
+	switch g.Artifacts {
+	case "", GPGSignAll, GPGSignChecksum:
+		return nil
+	}
+	return errors.Errorf("gpg artifacts must be %s or %s, got %q", GPGSignAll, GPGSignChecksum, g.Artifacts)
+}
+
+// Signs returns whether artifact of type is signed with gpg
+func (g *GPG) Signs(typ ArtifactType) bool {
+	if g.Artifacts == GPGSignChecksum {
+		return typ == ArtifactChecksum
+	}
+	return true
+}
+
+// Entity loads private key from file or env and decrypts it
+// with passphrase from env if it's encrypted
+func (g *GPG) Entity() (*openpgp.Entity, error) {
+	keyEnv, passEnv := g.KeyEnv, g.PassphraseEnv
+	if keyEnv == "" {
+		keyEnv = DefaultGPGKeyEnv
+	}
+	if passEnv == "" {
+		passEnv = DefaultGPGPassphraseEnv
+	}
+
+	var b []byte
+	var err error
+	switch {
+	case g.Key != "":
+		if b, err = ioutil.ReadFile(g.Key); err != nil {
+			return nil, err
+		}
+	case os.Getenv(keyEnv) != "":
+		b = []byte(os.Getenv(keyEnv))
+	default:
+		return nil, ErrorGPGKeyNotSet
+	}
+	return readGPGEntity(b, os.Getenv(passEnv))
+}
+
+func readGPGEntity(key []byte, passphrase string) (*openpgp.Entity, error) {
+	ring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(key))
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range ring {
+		if e.PrivateKey == nil {
+			continue
+		}
+		if e.PrivateKey.Encrypted {
+			if err = e.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+				return nil, errors.Wrap(err, "while decrypting gpg key")
+			}
+		}
+		for _, sub := range e.Subkeys {
+			if sub.PrivateKey != nil && sub.PrivateKey.Encrypted {
+				if err = sub.PrivateKey.Decrypt([]byte(passphrase)); err != nil {
+					return nil, errors.Wrap(err, "while decrypting gpg subkey")
+				}
+			}
+		}
+		return e, nil
+	}
+	return nil, ErrorGPGNoSigner
+}
+
+// GPGSign writes ASCII-armored detached signature of message
+func GPGSign(w io.Writer, signer *openpgp.Entity, message io.Reader, date time.Time) error {
+	config := &packet.Config{Time: func() time.Time { return date }}
+	return openpgp.ArmoredDetachSign(w, signer, message, config)
+}
+
+func gpgFile(signer *openpgp.Entity, file string, meta Meta) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	if err = GPGSign(&buf, signer, f, meta.Date); err != nil {
+		return err
+	}
+	buf.WriteString("\n")
+	return ioutil.WriteFile(file+GPGExt, buf.Bytes(), 0644)
+}
+
+// VerifyGPG verifies armored detached signature of file name
+// with armored public key ring
+func VerifyGPG(ctx context.Context, keyring []byte, name string, open OpenFunc) error {
+	ring, err := openpgp.ReadArmoredKeyRing(bytes.NewReader(keyring))
+	if err != nil {
+		return err
+	}
+	sig, err := open(ctx, name+GPGExt)
+	if err != nil {
+		return &SignError{Path: name, Err: err}
+	}
+	defer sig.Close()
+	f, err := open(ctx, name)
+	if err != nil {
+		return &SignError{Path: name, Err: err}
+	}
+	defer f.Close()
+	if _, err = openpgp.CheckArmoredDetachedSignature(ring, f, sig); err != nil {
+		return &SignError{Path: name, Err: err}
+	}
+	return nil
+}
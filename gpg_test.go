@@ -0,0 +1,97 @@
+package gorelease_test
+
+import (
+	"bytes"
+	"context"
+	. "github.com/bukowa/gorelease"
+	"golang.org/x/crypto/openpgp"
+	"golang.org/x/crypto/openpgp/armor"
+	"gopkg.in/yaml.v2"
+	"os"
+	"testing"
+)
+
+func gpgKeys(t *testing.T) (private, public []byte) {
+	e, err := openpgp.NewEntity("gorelease", "test", "test@example.com", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var priv, pub bytes.Buffer
+	w, err := armor.Encode(&priv, openpgp.PrivateKeyType, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = e.SerializePrivate(w, nil); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	if w, err = armor.Encode(&pub, openpgp.PublicKeyType, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err = e.Serialize(w); err != nil {
+		t.Fatal(err)
+	}
+	w.Close()
+	return priv.Bytes(), pub.Bytes()
+}
+
+func TestSignRelease_gpg(t *testing.T) {
+	priv, pub := gpgKeys(t)
+	os.Setenv(DefaultGPGKeyEnv, string(priv))
+	defer os.Unsetenv(DefaultGPGKeyEnv)
+
+	var tests = []struct {
+		artifacts string
+		want      int
+	}{
+		{GPGSignAll, 3},
+		{GPGSignChecksum, 1},
+	}
+	for _, tt := range tests {
+		r := checksumRelease(t, ChecksumSHA256)
+		r.Sign.GPG = &GPG{Artifacts: tt.artifacts}
+
+		if err := ChecksumRelease(r); err != nil {
+			t.Fatal(err)
+		}
+		if err := SignRelease(r); err != nil {
+			t.Fatal(err)
+		}
+
+		var sigs int
+		for _, a := range r.Artifacts() {
+			if a.Type != ArtifactSignature {
+				continue
+			}
+			sigs++
+			if _, err := os.Stat(a.Path); err != nil {
+				t.Error(err)
+			}
+		}
+		if sigs != tt.want {
+			t.Errorf("%s got: %v signatures want: %v", tt.artifacts, sigs, tt.want)
+		}
+
+		ctx := context.Background()
+		if err := VerifyGPG(ctx, pub, DefaultChecksumName, DirOpener(r.Dir)); err != nil {
+			t.Error(err)
+		}
+		if err := VerifyGPG(ctx, pub, "linux_amd64/app", DirOpener(r.Dir)); (err == nil) != (tt.artifacts == GPGSignAll) {
+			t.Errorf("%s got: %v", tt.artifacts, err)
+		}
+		os.RemoveAll(r.DestDir)
+	}
+}
+
+func TestGPG_artifacts(t *testing.T) {
+	var r Release
+	if err := yaml.Unmarshal([]byte("sign:\n  gpg:\n    artifacts: checksum\n"), &r); err != nil {
+		t.Fatal(err)
+	}
+	if r.Sign.GPG.Artifacts != GPGSignChecksum {
+		t.Errorf("got: %v", r.Sign.GPG)
+	}
+	if err := yaml.Unmarshal([]byte("sign:\n  gpg:\n    artifacts: checksums\n"), &r); err == nil {
+		t.Error("expected error of unknown artifacts")
+	}
+}
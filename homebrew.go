@@ -0,0 +1,216 @@
+package gorelease
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// DefaultHomebrewMessage is a default template of commit message of formula
+const DefaultHomebrewMessage = "{{.Target}} {{.Version}}"
+
+// Homebrew configures formula rendered from release manifest,
+// with archives of darwin and linux builds of one target
+type Homebrew struct {
+	Target       string        `yaml:"target"`       // name of target, first target if empty
+	Name         string        `yaml:"name"`         // name of formula, name of target if empty
+	Description  string        `yaml:"description"`  // desc of formula
+	Homepage     string        `yaml:"homepage"`     // homepage of formula
+	License      string        `yaml:"license"`      // SPDX license identifier
+	Dependencies []string      `yaml:"dependencies"` // formulae of depends_on
+	Install      string        `yaml:"install"`      // ruby of install method, installs executable per platform if empty
+	Test         string        `yaml:"test"`         // ruby of test block, runs executable with --version if empty
+	Caveats      string        `yaml:"caveats"`      // text shown after install
+	Dir          string        `yaml:"dir"`          // dir of formula in tap, Formula if empty
+	Tap          GitRepository `yaml:"tap"`          // formula is committed into tap if dir is set
+}
+
+// homebrew blocks and hardware conditions of platforms
+var (
+	homebrewOS = []struct{ goos, block string }{
+		{"darwin", "on_macos"},
+		{"linux", "on_linux"},
+	}
+	homebrewArch = []struct{ goarch, condition string }{
+		{"amd64", "Hardware::CPU.intel?"},
+		{"arm64", "Hardware::CPU.arm? && Hardware::CPU.is_64_bit?"},
+		{"arm", "Hardware::CPU.arm? && !Hardware::CPU.is_64_bit?"},
+	}
+)
+
+var ErrorHomebrewNoBuilds = errors.New("formula target has no darwin or linux artifacts")
+
+// HomebrewRelease is a basic ReleaseFunc writing formula
+var HomebrewRelease ReleaseFunc = func(release *Release) error {
+	return HomebrewReleaseContext(context.Background(), release)
+}
+
+// HomebrewReleaseContext is a basic ReleaseContextFunc writing formula from
+// manifest of published release, it's committed into tap if it's configured
+// or written into release dir otherwise
+var HomebrewReleaseContext ReleaseContextFunc = func(ctx context.Context, release *Release) error {
+	h := release.Homebrew
+	if h == nil {
+		return nil
+	}
+	m, err := releaseManifest(release)
+	if err != nil {
+		return err
+	}
+	formula, err := MakeHomebrewFormula(release, m)
+	if err != nil {
+		return err
+	}
+	name := h.FormulaName(release)
+	if h.Tap.Dir == "" {
+		return writeReleaseFile(release, name+".rb", formula)
+	}
+	dir := h.Dir
+	if dir == "" {
+		dir = "Formula"
+	}
+	message, err := commitMessage(release, name, h.Tap.Message, DefaultHomebrewMessage)
+	if err != nil {
+		return err
+	}
+	return h.Tap.CommitFiles(ctx, map[string][]byte{path.Join(dir, name+".rb"): formula}, message)
+}
+
+// FormulaName returns name of formula
+func (h *Homebrew) FormulaName(r *Release) string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return targetName(r, h.Target)
+}
+
+// MakeHomebrewFormula renders formula with urls and sha256 of
+// archives, or executables if builds are not archived, from Manifest,
+// each platform installs its own executable unless Install is set
+func MakeHomebrewFormula(r *Release, m *Manifest) ([]byte, error) {
+	h := r.Homebrew
+	target, err := r.TargetNamed(h.Target)
+	if err != nil {
+		return nil, err
+	}
+	name := h.FormulaName(r)
+
+	var b strings.Builder
+	b.WriteString("# typed: false\n# frozen_string_literal: true\n")
+	b.WriteString("# This file was generated by gorelease. DO NOT EDIT.\n")
+	fmt.Fprintf(&b, "class %s < Formula\n", homebrewClass(name))
+	for _, f := range [][2]string{
+		{"desc", h.Description},
+		{"homepage", h.Homepage},
+		{"version", strings.TrimPrefix(m.Version, "v")},
+		{"license", h.License},
+	} {
+		if f[1] != "" {
+			fmt.Fprintf(&b, "  %s %s\n", f[0], rubyString(f[1]))
+		}
+	}
+
+	// executable of first platform is run by default test
+	var bin string
+	var found bool
+	for _, platform := range homebrewOS {
+		var blocks []string
+		for _, arch := range homebrewArch {
+			a := m.Artifact(target.Name, platform.goos, arch.goarch)
+			if a == nil {
+				continue
+			}
+			if a.URL == "" {
+				return nil, errors.Errorf("artifact %s has no url", a.Name)
+			}
+			fb, err := MakeFileBuild(*target, platform.goos, arch.goarch)
+			if err != nil {
+				return nil, err
+			}
+			if bin == "" {
+				bin = fb.Name
+			}
+			block := fmt.Sprintf("    if %s\n      url %s\n      sha256 %s\n", arch.condition, rubyString(a.URL), rubyString(a.SHA256))
+			if h.Install == "" {
+				block += fmt.Sprintf("\n      def install\n%s      end\n", indent(homebrewInstall(a, fb.Name), "        "))
+			}
+			blocks = append(blocks, block+"    end\n")
+		}
+		if len(blocks) == 0 {
+			continue
+		}
+		found = true
+		fmt.Fprintf(&b, "\n  %s do\n%s  end\n", platform.block, strings.Join(blocks, ""))
+	}
+	if !found {
+		return nil, ErrorHomebrewNoBuilds
+	}
+
+	if len(h.Dependencies) > 0 {
+		b.WriteString("\n")
+	}
+	for _, d := range h.Dependencies {
+		fmt.Fprintf(&b, "  depends_on %s\n", rubyString(d))
+	}
+
+	if h.Install != "" {
+		fmt.Fprintf(&b, "\n  def install\n%s  end\n", indent(h.Install, "    "))
+	}
+	if h.Caveats != "" {
+		fmt.Fprintf(&b, "\n  def caveats\n    <<~EOS\n%s    EOS\n  end\n", indent(h.Caveats, "      "))
+	}
+	test := h.Test
+	if test == "" {
+		test = fmt.Sprintf(`system "#{bin}/%s", "--version"`, strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(bin))
+	}
+	fmt.Fprintf(&b, "\n  test do\n%s  end\nend\n", indent(test, "    "))
+	return []byte(b.String()), nil
+}
+
+// homebrewInstall returns ruby installing executable of artifact,
+// downloaded executable is named like base of url
+func homebrewInstall(a *ManifestArtifact, bin string) string {
+	if a.Type == ArtifactBinary {
+		return fmt.Sprintf("bin.install File.basename(stable.url) => %s", rubyString(bin))
+	}
+	return fmt.Sprintf("bin.install %s", rubyString(bin))
+}
+
+// homebrewClass returns class name of formula like homebrew,
+// go-release becomes GoRelease and app@2 becomes AppAT2
+func homebrewClass(name string) string {
+	name = strings.Replace(name, "@", "AT", -1)
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// rubyString returns double quoted ruby string literal
+func rubyString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, `#{`, `\#{`, "\n", `\n`).Replace(s) + `"`
+}
+
+// indent prefixes every non empty line of text and ends it with newline
+func indent(text, prefix string) string {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = prefix + line
+		}
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
@@ -0,0 +1,213 @@
+package gorelease_test
+
+import (
+	"context"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// manifestRelease returns published release of target app with manifest
+// of archives, darwin/amd64 build is executable
+func manifestRelease(t *testing.T) *Release {
+	dir, err := ioutil.TempDir("", "gorelease")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := Target{Name: "app", Version: "v1.2.0", DestDir: dir}
+	r := &Release{Target: target, Targets: []Target{target}, Dir: filepath.Join(dir, "v1.2.0")}
+	if err = os.MkdirAll(r.Dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	r.Manifest = &Manifest{Version: "v1.2.0"}
+	for _, p := range []struct{ goos, goarch, typ, ext string }{
+		{"darwin", "amd64", "binary", ""},
+		{"darwin", "arm64", "archive", ".tar.gz"},
+		{"linux", "amd64", "archive", ".tar.gz"},
+		{"linux", "arm64", "archive", ".tar.gz"},
+		{"linux", "386", "archive", ".tar.gz"},
+		{"windows", "386", "archive", ".zip"},
+		{"windows", "amd64", "archive", ".zip"},
+		{"windows", "arm64", "archive", ".zip"},
+	} {
+		name := p.goos + "_" + p.goarch + "/app_v1.2.0_" + p.goos + "_" + p.goarch + p.ext
+		if p.typ == "binary" {
+			name = p.goos + "_" + p.goarch + "/app"
+		}
+		r.Manifest.Artifacts = append(r.Manifest.Artifacts, ManifestArtifact{
+			Name:   name,
+			Type:   ArtifactType(p.typ),
+			Target: "app",
+			Os:     p.goos,
+			Arch:   p.goarch,
+			URL:    "https://example.com/v1.2.0/" + name,
+			SHA256: strings.Repeat(p.goarch[:1], 64),
+		})
+	}
+	return r
+}
+
+// git runs git in dir
+func git(t *testing.T, dir string, args ...string) string {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %s %v", args, out, err)
+	}
+	return string(out)
+}
+
+// tapRepository returns checkout cloned from bare repository
+func tapRepository(t *testing.T, dir string) (checkout, bare string) {
+	bare, checkout = filepath.Join(dir, "bare.git"), filepath.Join(dir, "checkout")
+	git(t, dir, "init", "--bare", bare)
+	git(t, dir, "clone", bare, checkout)
+	return checkout, bare
+}
+
+func TestMakeHomebrewFormula(t *testing.T) {
+	r := manifestRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	r.Homebrew = &Homebrew{
+		Name:         "go-app",
+		Description:  `App "with" quotes`,
+		Homepage:     "https://example.com",
+		License:      "MIT",
+		Dependencies: []string{"git"},
+		Caveats:      "Run app init",
+	}
+	b, err := MakeHomebrewFormula(r, r.Manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `# typed: false
+# frozen_string_literal: true
+# This file was generated by gorelease. DO NOT EDIT.
+class GoApp < Formula
+  desc "App \"with\" quotes"
+  homepage "https://example.com"
+  version "1.2.0"
+  license "MIT"
+
+  on_macos do
+    if Hardware::CPU.intel?
+      url "https://example.com/v1.2.0/darwin_amd64/app"
+      sha256 "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+      def install
+        bin.install File.basename(stable.url) => "app"
+      end
+    end
+    if Hardware::CPU.arm? && Hardware::CPU.is_64_bit?
+      url "https://example.com/v1.2.0/darwin_arm64/app_v1.2.0_darwin_arm64.tar.gz"
+      sha256 "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+      def install
+        bin.install "app"
+      end
+    end
+  end
+
+  on_linux do
+    if Hardware::CPU.intel?
+      url "https://example.com/v1.2.0/linux_amd64/app_v1.2.0_linux_amd64.tar.gz"
+      sha256 "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+      def install
+        bin.install "app"
+      end
+    end
+    if Hardware::CPU.arm? && Hardware::CPU.is_64_bit?
+      url "https://example.com/v1.2.0/linux_arm64/app_v1.2.0_linux_arm64.tar.gz"
+      sha256 "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+
+      def install
+        bin.install "app"
+      end
+    end
+  end
+
+  depends_on "git"
+
+  def caveats
+    <<~EOS
+      Run app init
+    EOS
+  end
+
+  test do
+    system "#{bin}/app", "--version"
+  end
+end
+`
+	if string(b) != want {
+		t.Errorf("got:\n%s", b)
+	}
+
+	// executable is named per platform
+	name := `app{{if eq .Os "linux"}}-linux{{end}}`
+	r.Targets[0].Name = name
+	for i := range r.Manifest.Artifacts {
+		r.Manifest.Artifacts[i].Target = name
+	}
+	r.Homebrew = &Homebrew{Name: "app"}
+	if b, err = MakeHomebrewFormula(r, r.Manifest); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"darwin_amd64/app\"\n      sha256 \"" + strings.Repeat("a", 64) + "\"\n\n      def install\n        bin.install File.basename(stable.url) => \"app\"\n",
+		"linux_amd64/app_v1.2.0_linux_amd64.tar.gz\"\n      sha256 \"" + strings.Repeat("a", 64) + "\"\n\n      def install\n        bin.install \"app-linux\"\n",
+		"system \"#{bin}/app\", \"--version\"",
+	} {
+		if !strings.Contains(string(b), s) {
+			t.Errorf("no %q in:\n%s", s, b)
+		}
+	}
+}
+
+func TestHomebrewRelease(t *testing.T) {
+	r := manifestRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	checkout, bare := tapRepository(t, r.DestDir)
+	r.Homebrew = &Homebrew{
+		Install: "bin.install \"app\"\nman1.install \"app.1\"",
+		Tap: GitRepository{
+			Dir:    checkout,
+			Remote: "origin",
+			Branch: "main",
+			Author: "Release Bot <bot@example.com>",
+		},
+	}
+	if err := HomebrewRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	if log := git(t, bare, "log", "--format=%an %s", "main"); log != "Release Bot app v1.2.0\n" {
+		t.Errorf("got: %q", log)
+	}
+	formula := git(t, bare, "show", "main:Formula/app.rb")
+	if !strings.Contains(formula, "class App < Formula") || !strings.Contains(formula, "    bin.install \"app\"\n    man1.install \"app.1\"\n") {
+		t.Errorf("got: %s", formula)
+	}
+
+	// unchanged formula is not committed again
+	if err := HomebrewReleaseContext(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	if log := git(t, bare, "log", "--oneline", "main"); strings.Count(log, "\n") != 1 {
+		t.Errorf("got: %q", log)
+	}
+
+	// without tap formula is written into release dir
+	r.Homebrew.Tap = GitRepository{}
+	if err := HomebrewRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	if b, err := ioutil.ReadFile(filepath.Join(r.Dir, "app.rb")); err != nil || string(b) != formula {
+		t.Errorf("got: %s %v", b, err)
+	}
+}
@@ -0,0 +1,173 @@
+package gorelease
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HTTPError is returned when server responds with non 2xx status,
+// Body is the body of the response
+type HTTPError struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Body       string
+}
+
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%s %s: %d %s: %s", e.Method, e.URL, e.StatusCode, http.StatusText(e.StatusCode), e.Body)
+}
+
+// httpClient is client of JSON API
+type httpClient struct {
+	client *http.Client
+	header http.Header // set on every request
+}
+
+// do sends request and decodes JSON response into v unless v is nil
+func (c *httpClient) do(req *http.Request, v interface{}) error {
+	_, err := c.send(req, v)
+	return err
+}
+
+// send is do returning header of response
+func (c *httpClient) send(req *http.Request, v interface{}) (http.Header, error) {
+	for k, vs := range c.header {
+		req.Header[k] = vs
+	}
+	client := c.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, &HTTPError{Method: req.Method, URL: req.URL.String(), StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+	}
+	if v == nil {
+		_, err = io.Copy(ioutil.Discard, resp.Body)
+	} else {
+		err = json.NewDecoder(resp.Body).Decode(v)
+	}
+	return resp.Header, err
+}
+
+// page gets JSON page of list into v and returns url of next page
+// from Link header, empty if it's the last page
+func (c *httpClient) page(ctx context.Context, url string, v interface{}) (string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	header, err := c.send(req.WithContext(ctx), v)
+	if err != nil {
+		return "", err
+	}
+	return nextLink(header.Get("Link")), nil
+}
+
+// nextLink returns url of rel="next" link of Link header
+func nextLink(link string) string {
+	for _, l := range strings.Split(link, ",") {
+		parts := strings.Split(l, ";")
+		u := strings.TrimSpace(parts[0])
+		if !strings.HasPrefix(u, "<") || !strings.HasSuffix(u, ">") {
+			continue
+		}
+		for _, param := range parts[1:] {
+			if strings.TrimSpace(param) == `rel="next"` {
+				return u[1 : len(u)-1]
+			}
+		}
+	}
+	return ""
+}
+
+// isNotFound reports whether err is HTTPError with 404 status
+func isNotFound(err error) bool {
+	e, ok := err.(*HTTPError)
+	return ok && e.StatusCode == http.StatusNotFound
+}
+
+// json sends JSON encoded body unless it's nil
+func (c *httpClient) json(ctx context.Context, method, url string, body, v interface{}) error {
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, url, r)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.do(req, v)
+}
+
+// upload sends content of file as body
+func (c *httpClient) upload(ctx context.Context, method, url, file string, header http.Header, v interface{}) error {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, url, bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = int64(len(b))
+	for k, vs := range header {
+		req.Header[k] = vs
+	}
+	if req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType(file))
+	}
+	return c.do(req, v)
+}
+
+// uploadForm sends content of file as multipart form field
+func (c *httpClient) uploadForm(ctx context.Context, url, field, file string, v interface{}) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile(field, filepath.Base(file))
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(part, f); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, url, &buf)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return c.do(req, v)
+}
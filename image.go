@@ -0,0 +1,549 @@
+package gorelease
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DefaultImagePath is a default template of path of binary in image
+const DefaultImagePath = "/usr/local/bin/{{.Target}}"
+
+// DefaultImageEnv is env of images without env
+var DefaultImageEnv = []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin"}
+
+// OCILayoutVersion is version of written OCI image layouts
+const OCILayoutVersion = "1.0.0"
+
+// Image configures multi-arch OCI image assembled from scratch
+// with linux FileBuilds of one target, without docker daemon
+type Image struct {
+	Target     string            `yaml:"target"`      // name of target, first target if empty
+	Path       string            `yaml:"path"`        // template of binary path, DefaultImagePath if empty
+	Entrypoint []string          `yaml:"entrypoint"`  // binary path if empty
+	Cmd        []string          `yaml:"cmd"`         // default arguments
+	Env        []string          `yaml:"env"`         // DefaultImageEnv if empty
+	User       string            `yaml:"user"`        // like 65532:65532 of distroless nonroot
+	WorkingDir string            `yaml:"working_dir"` // working directory
+	Labels     map[string]string `yaml:"labels"`      // templates of labels
+	Files      []ImageFile       `yaml:"files"`       // extra files
+	Layout     string            `yaml:"layout"`      // path of OCI layout, image in release dir if empty
+	Tarball    bool              `yaml:"tarball"`     // write layout as tar archive with .tar extension
+	Tags       []string          `yaml:"tags"`        // templates of tags, version if empty
+
+	// pushed by release image
+	Repository  string `yaml:"repository"` // like registry.example.com/team/app
+	Insecure    bool   `yaml:"insecure"`   // use plain http
+	UsernameEnv string `yaml:"username_env"`
+	PasswordEnv string `yaml:"password_env"`
+}
+
+// ImageFile is extra file of image
+type ImageFile struct {
+	Src  string      `yaml:"src"`  // path on disk
+	Dst  string      `yaml:"dst"`  // absolute path in image
+	Mode os.FileMode `yaml:"mode"` // mode of file on disk if zero
+}
+
+// ImageError is returned when image can't be created or pushed
+type ImageError struct {
+	Path string // path of layout
+	Err  error
+}
+
+func (e *ImageError) Error() string {
+	return fmt.Sprintf("image %s: %s", e.Path, e.Err)
+}
+
+func (e *ImageError) Unwrap() error {
+	return e.Err
+}
+
+var ErrorImageNoLinuxBuilds = errors.New("image target has no linux builds")
+
+// ociImageConfig is config of OCI image
+type ociImageConfig struct {
+	Created      string `json:"created"`
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+	Config       struct {
+		User       string            `json:"User,omitempty"`
+		Env        []string          `json:"Env,omitempty"`
+		Entrypoint []string          `json:"Entrypoint,omitempty"`
+		Cmd        []string          `json:"Cmd,omitempty"`
+		WorkingDir string            `json:"WorkingDir,omitempty"`
+		Labels     map[string]string `json:"Labels,omitempty"`
+	} `json:"config"`
+	RootFS struct {
+		Type    string   `json:"type"`
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+	History []map[string]string `json:"history"`
+}
+
+// ImageLayoutPath returns path of OCI layout dir or tarball of prepared Release
+func (r *Release) ImageLayoutPath() string {
+	p := r.Image.Layout
+	if p == "" {
+		p = path.Join(r.Dir, "image")
+	}
+	if r.Image.Tarball && !strings.HasSuffix(p, ".tar") {
+		p += ".tar"
+	}
+	return p
+}
+
+// ImageTags returns tags of image of prepared Release
+func (r *Release) ImageTags() ([]string, error) {
+	version, err := r.ExpandedVersion()
+	if err != nil {
+		return nil, err
+	}
+	if len(r.Image.Tags) == 0 {
+		return []string{OCITag(version)}, nil
+	}
+	data := MakeTemplateData(r.Target, "", "")
+	data.Version = version
+	var tags []string
+	for _, t := range r.Image.Tags {
+		tag, err := ExecTemplate("image.tags", t, data)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, OCITag(tag))
+	}
+	return tags, nil
+}
+
+// ImageRelease is a basic BuildReleaseFunc writing OCI image layout
+var ImageRelease BuildReleaseFunc = func(release *Release) error {
+	return ImageReleaseContext(context.Background(), release)
+}
+
+// ImageReleaseContext is a basic BuildReleaseContextFunc writing OCI layout
+// with image index of linux FileBuilds if release has image config
+var ImageReleaseContext BuildReleaseContextFunc = func(ctx context.Context, release *Release) error {
+	if release.Image == nil {
+		return nil
+	}
+	layout := release.ImageLayoutPath()
+	tags, err := release.ImageTags()
+	if err != nil {
+		return &ImageError{Path: layout, Err: err}
+	}
+	blobs, err := MakeImage(ctx, release)
+	if err != nil {
+		return &ImageError{Path: layout, Err: err}
+	}
+	log.Printf("writing image layout: %s", layout)
+	if err = WriteOCILayout(layout, release.Image.Tarball, blobs, tags...); err != nil {
+		return &ImageError{Path: layout, Err: err}
+	}
+	return nil
+}
+
+// MakeImage creates blobs of image of prepared Release,
+// last blob is image index of platforms
+func MakeImage(ctx context.Context, r *Release) ([]OCIBlob, error) {
+	img := r.Image
+	target, err := r.TargetNamed(r.Image.Target)
+	if err != nil {
+		return nil, err
+	}
+	version, err := r.ExpandedVersion()
+	if err != nil {
+		return nil, err
+	}
+	// commit date keeps digests reproducible
+	mtime := archiveTime(r.Meta)
+	created := mtime.UTC().Format(time.RFC3339)
+
+	var blobs []OCIBlob
+	index := OCIIndex{SchemaVersion: 2, MediaType: MediaTypeOCIIndex}
+	for _, b := range target.FileBuilds {
+		if b.GOOS != "linux" {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data := MakeTemplateData(*target, b.GOOS, b.GOARCH)
+		data.Version = version
+		binPath := img.Path
+		if binPath == "" {
+			binPath = DefaultImagePath
+		}
+		binPath, err = ExecTemplate("image.path", binPath, data)
+		if err != nil {
+			return nil, err
+		}
+
+		// single layer with binary and extra files
+		files := []ImageFile{{Src: b.BinPath, Dst: binPath, Mode: 0755}}
+		files = append(files, img.Files...)
+		layer, diffID, err := makeImageLayer(files, mtime)
+		if err != nil {
+			return nil, err
+		}
+
+		var config ociImageConfig
+		config.Created = created
+		config.OS, config.Architecture = b.GOOS, b.GOARCH
+		config.Variant = OCIPlatformOf(b.GOOS, b.GOARCH).Variant
+		config.Config.User = img.User
+		config.Config.Env = img.Env
+		if len(config.Config.Env) == 0 {
+			config.Config.Env = DefaultImageEnv
+		}
+		config.Config.Entrypoint = img.Entrypoint
+		if len(config.Config.Entrypoint) == 0 {
+			config.Config.Entrypoint = []string{binPath}
+		}
+		config.Config.Cmd = img.Cmd
+		config.Config.WorkingDir = img.WorkingDir
+		config.Config.Labels = map[string]string{
+			AnnotationVersion: version,
+			AnnotationCreated: created,
+		}
+		if r.Meta.Commit != "" {
+			config.Config.Labels[AnnotationRevision] = r.Meta.Commit
+		}
+		for k, v := range img.Labels {
+			if config.Config.Labels[k], err = ExecTemplate("image.labels."+k, v, data); err != nil {
+				return nil, err
+			}
+		}
+		config.RootFS.Type = "layers"
+		config.RootFS.DiffIDs = []string{diffID}
+		config.History = []map[string]string{{"created": created, "created_by": "gorelease"}}
+
+		configBlob, err := NewOCIJSONBlob(MediaTypeOCIConfig, config)
+		if err != nil {
+			return nil, err
+		}
+		manifest, err := NewOCIJSONBlob(MediaTypeOCIManifest, OCIManifest{
+			SchemaVersion: 2,
+			MediaType:     MediaTypeOCIManifest,
+			Config:        configBlob.OCIDescriptor,
+			Layers:        []OCIDescriptor{layer.OCIDescriptor},
+		})
+		if err != nil {
+			return nil, err
+		}
+		blobs = append(blobs, layer, configBlob, manifest)
+		manifest.Platform = OCIPlatformOf(b.GOOS, b.GOARCH)
+		index.Manifests = append(index.Manifests, manifest.OCIDescriptor)
+	}
+	if len(index.Manifests) == 0 {
+		return nil, ErrorImageNoLinuxBuilds
+	}
+
+	blob, err := NewOCIJSONBlob(MediaTypeOCIIndex, index)
+	if err != nil {
+		return nil, err
+	}
+	return append(blobs, blob), nil
+}
+
+// makeImageLayer creates gzipped tar layer of files and returns it
+// with digest of uncompressed tar
+func makeImageLayer(files []ImageFile, mtime time.Time) (OCIBlob, string, error) {
+	var raw bytes.Buffer
+	tw := tar.NewWriter(&raw)
+	dirs := map[string]bool{}
+	sort.SliceStable(files[1:], func(i, j int) bool { return files[i+1].Dst < files[j+1].Dst })
+	for _, f := range files {
+		dst := strings.TrimPrefix(path.Clean("/"+f.Dst), "/")
+		// parent directories
+		var parents []string
+		for dir := path.Dir(dst); dir != "." && !dirs[dir]; dir = path.Dir(dir) {
+			parents = append([]string{dir}, parents...)
+			dirs[dir] = true
+		}
+		for _, dir := range parents {
+			hdr := &tar.Header{Typeflag: tar.TypeDir, Name: dir + "/", Mode: 0755, ModTime: mtime, Format: tar.FormatPAX}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return OCIBlob{}, "", err
+			}
+		}
+
+		info, err := os.Stat(f.Src)
+		if err != nil {
+			return OCIBlob{}, "", err
+		}
+		mode := f.Mode
+		if mode == 0 {
+			mode = info.Mode().Perm()
+		}
+		hdr := &tar.Header{Typeflag: tar.TypeReg, Name: dst, Mode: int64(mode), Size: info.Size(), ModTime: mtime, Format: tar.FormatPAX}
+		if err = tw.WriteHeader(hdr); err != nil {
+			return OCIBlob{}, "", err
+		}
+		if err = copyFile(tw, f.Src); err != nil {
+			return OCIBlob{}, "", err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return OCIBlob{}, "", err
+	}
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	if _, err := zw.Write(raw.Bytes()); err != nil {
+		return OCIBlob{}, "", err
+	}
+	if err := zw.Close(); err != nil {
+		return OCIBlob{}, "", err
+	}
+	return NewOCIBlob(MediaTypeOCILayerGzip, gz.Bytes()), Digest(raw.Bytes()), nil
+}
+
+// WriteOCILayout writes blobs into OCI image layout directory or tarball,
+// last blob is referenced by index.json with ref names of tags
+func WriteOCILayout(layout string, tarball bool, blobs []OCIBlob, tags ...string) error {
+	top := blobs[len(blobs)-1]
+	index := OCIIndex{SchemaVersion: 2, MediaType: MediaTypeOCIIndex}
+	if len(tags) == 0 {
+		index.Manifests = append(index.Manifests, top.OCIDescriptor)
+	}
+	for _, tag := range tags {
+		d := top.OCIDescriptor
+		d.Annotations = map[string]string{AnnotationRefName: tag}
+		index.Manifests = append(index.Manifests, d)
+	}
+	indexJSON, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	files := map[string][]byte{
+		"oci-layout": []byte(`{"imageLayoutVersion":"` + OCILayoutVersion + `"}`),
+		"index.json": indexJSON,
+	}
+	for _, b := range blobs {
+		files["blobs/"+strings.Replace(b.Digest, ":", "/", 1)] = b.Data
+	}
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	if err = os.RemoveAll(layout); err != nil {
+		return err
+	}
+	if !tarball {
+		for _, name := range names {
+			p := filepath.Join(layout, filepath.FromSlash(name))
+			if err = os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+				return err
+			}
+			if err = ioutil.WriteFile(p, files[name], 0644); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err = os.MkdirAll(filepath.Dir(layout), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(layout)
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(f)
+	for _, name := range names {
+		hdr := &tar.Header{Typeflag: tar.TypeReg, Name: name, Mode: 0644, Size: int64(len(files[name]))}
+		if err = tw.WriteHeader(hdr); err != nil {
+			break
+		}
+		if _, err = tw.Write(files[name]); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	return closeAfter(f, err)
+}
+
+// ReadOCILayout reads files of OCI image layout directory or tarball
+func ReadOCILayout(layout string) (map[string][]byte, error) {
+	files := map[string][]byte{}
+	info, err := os.Stat(layout)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		err = filepath.Walk(layout, func(p string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(layout, p)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)], err = ioutil.ReadFile(p)
+			return err
+		})
+		return files, err
+	}
+	f, err := os.Open(layout)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return files, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if files[strings.TrimPrefix(hdr.Name, "./")], err = ioutil.ReadAll(tr); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// PushImage pushes OCI layout of prepared Release to Image.Repository
+// with tags of image
+func PushImage(ctx context.Context, r *Release) error {
+	if r.Image == nil {
+		return errors.New("config has no image")
+	}
+	if r.Image.Repository == "" {
+		return ErrorRepositoryNotSet
+	}
+	host, repo, err := ParseRegistryReference(r.Image.Repository)
+	if err != nil {
+		return err
+	}
+	tags, err := r.ImageTags()
+	if err != nil {
+		return err
+	}
+	reg := &Registry{
+		Host:       host,
+		Repository: repo,
+		Username:   os.Getenv(envOr(r.Image.UsernameEnv, DefaultOCIUsernameEnv)),
+		Password:   os.Getenv(envOr(r.Image.PasswordEnv, DefaultOCIPasswordEnv)),
+		Insecure:   r.Image.Insecure,
+	}
+	return PushOCILayout(ctx, reg, r.ImageLayoutPath(), tags)
+}
+
+// PushOCILayout pushes every image of OCI layout, tagged with ref names
+// of index.json, or with tags if image has no ref name
+func PushOCILayout(ctx context.Context, reg *Registry, layout string, tags []string) error {
+	files, err := ReadOCILayout(layout)
+	if err != nil {
+		return &ImageError{Path: layout, Err: err}
+	}
+	blob := func(d OCIDescriptor) (OCIBlob, error) {
+		b, ok := files["blobs/"+strings.Replace(d.Digest, ":", "/", 1)]
+		if !ok {
+			return OCIBlob{}, errors.Errorf("blob %s not found", d.Digest)
+		}
+		if Digest(b) != d.Digest {
+			return OCIBlob{}, errors.Errorf("blob %s has wrong digest", d.Digest)
+		}
+		return OCIBlob{OCIDescriptor: OCIDescriptor{MediaType: d.MediaType, Digest: d.Digest, Size: d.Size}, Data: b}, nil
+	}
+
+	// push descriptor with everything it references, manifests by digest
+	var push func(d OCIDescriptor) (OCIBlob, error)
+	push = func(d OCIDescriptor) (OCIBlob, error) {
+		b, err := blob(d)
+		if err != nil {
+			return b, err
+		}
+		switch d.MediaType {
+		case MediaTypeOCIIndex:
+			var index OCIIndex
+			if err = json.Unmarshal(b.Data, &index); err != nil {
+				return b, err
+			}
+			for _, m := range index.Manifests {
+				if _, err = push(m); err != nil {
+					return b, err
+				}
+			}
+		case MediaTypeOCIManifest:
+			var m OCIManifest
+			if err = json.Unmarshal(b.Data, &m); err != nil {
+				return b, err
+			}
+			for _, l := range append([]OCIDescriptor{m.Config}, m.Layers...) {
+				lb, err := blob(l)
+				if err != nil {
+					return b, err
+				}
+				if err = reg.PushBlob(ctx, lb); err != nil {
+					return b, err
+				}
+			}
+		default:
+			return b, reg.PushBlob(ctx, b)
+		}
+		return b, reg.PushManifest(ctx, d.Digest, b)
+	}
+
+	var index OCIIndex
+	if err = json.Unmarshal(files["index.json"], &index); err != nil {
+		return &ImageError{Path: layout, Err: errors.Wrap(err, "index.json")}
+	}
+	// entries of one image are pushed once and it's tagged with
+	// their ref names, or with tags if entry has no ref name
+	var images []OCIDescriptor
+	refs := map[string][]string{}
+	tagged := map[[2]string]bool{}
+	for _, d := range index.Manifests {
+		if _, ok := refs[d.Digest]; !ok {
+			images = append(images, d)
+			refs[d.Digest] = nil
+		}
+		names := tags
+		if ref := d.Annotations[AnnotationRefName]; ref != "" {
+			names = []string{ref}
+		}
+		for _, name := range names {
+			if !tagged[[2]string{d.Digest, name}] {
+				tagged[[2]string{d.Digest, name}] = true
+				refs[d.Digest] = append(refs[d.Digest], name)
+			}
+		}
+	}
+	for _, d := range images {
+		b, err := push(d)
+		if err != nil {
+			return &ImageError{Path: layout, Err: err}
+		}
+		for _, tag := range refs[d.Digest] {
+			log.Printf("pushing image %s/%s:%s", reg.Host, reg.Repository, tag)
+			if err = reg.PushManifest(ctx, tag, b); err != nil {
+				return &ImageError{Path: layout, Err: err}
+			}
+		}
+	}
+	return nil
+}
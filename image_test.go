@@ -0,0 +1,202 @@
+package gorelease_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	. "github.com/bukowa/gorelease"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func imageRelease(t *testing.T) *Release {
+	r := checksumRelease(t, ChecksumSHA256)
+	target := r.Targets[0]
+	b, err := MakeFileBuild(target, "linux", "arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(b.BinPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(b.BinPath, []byte("linux arm64"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	r.Targets[0].FileBuilds = append(r.Targets[0].FileBuilds, b)
+
+	conf := filepath.Join(r.DestDir, "app.yaml")
+	if err = ioutil.WriteFile(conf, []byte("port: 80"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	r.Image = &Image{
+		Path:   "/bin/{{.Target}}",
+		Cmd:    []string{"serve"},
+		User:   "65532:65532",
+		Labels: map[string]string{"arch": "{{.Arch}}"},
+		Files:  []ImageFile{{Src: conf, Dst: "/etc/app/app.yaml", Mode: 0644}},
+	}
+	return r
+}
+
+// layoutBlob returns blob of OCI layout dir
+func layoutBlob(t *testing.T, layout, digest string, v interface{}) []byte {
+	b, err := ioutil.ReadFile(filepath.Join(layout, "blobs", strings.Replace(digest, ":", "/", 1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Digest(b) != digest {
+		t.Fatalf("blob %s has wrong digest", digest)
+	}
+	if v != nil {
+		if err = json.Unmarshal(b, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return b
+}
+
+func TestImageRelease(t *testing.T) {
+	r := imageRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	if err := ImageRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	layout := r.ImageLayoutPath()
+
+	var top OCIIndex
+	b, err := ioutil.ReadFile(filepath.Join(layout, "index.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = json.Unmarshal(b, &top); err != nil || len(top.Manifests) != 1 || top.Manifests[0].Annotations[AnnotationRefName] != "v1.0.0" {
+		t.Fatalf("got: %s %v", b, err)
+	}
+	var index OCIIndex
+	layoutBlob(t, layout, top.Manifests[0].Digest, &index)
+	if len(index.Manifests) != 2 {
+		t.Fatalf("got: %+v", index)
+	}
+	arm := index.Manifests[1]
+	if arm.Platform == nil || arm.Platform.OS != "linux" || arm.Platform.Architecture != "arm64" || arm.Platform.Variant != "v8" {
+		t.Errorf("got: %+v", arm)
+	}
+
+	var manifest OCIManifest
+	layoutBlob(t, layout, arm.Digest, &manifest)
+	var config struct {
+		Architecture string
+		Config       struct {
+			User       string
+			Entrypoint []string
+			Cmd        []string
+			Labels     map[string]string
+		}
+		RootFS struct {
+			DiffIDs []string `json:"diff_ids"`
+		}
+	}
+	layoutBlob(t, layout, manifest.Config.Digest, &config)
+	c := config.Config
+	if config.Architecture != "arm64" || c.User != "65532:65532" || c.Entrypoint[0] != "/bin/app" || c.Cmd[0] != "serve" || c.Labels["arch"] != "arm64" || c.Labels[AnnotationVersion] != "v1.0.0" {
+		t.Errorf("got: %+v", config)
+	}
+
+	// layer has binary, extra file and parent dirs
+	gz := layoutBlob(t, layout, manifest.Layers[0].Digest, nil)
+	zr, err := gzip.NewReader(bytes.NewReader(gz))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if Digest(raw) != config.RootFS.DiffIDs[0] {
+		t.Errorf("got diff id: %v", config.RootFS.DiffIDs)
+	}
+	var names []string
+	tr := tar.NewReader(bytes.NewReader(raw))
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, hdr.Name)
+		if hdr.Name == "bin/app" {
+			if b, _ := ioutil.ReadAll(tr); string(b) != "linux arm64" || hdr.Mode != 0755 {
+				t.Errorf("got: %s %o", b, hdr.Mode)
+			}
+		}
+	}
+	if strings.Join(names, " ") != "bin/ bin/app etc/ etc/app/ etc/app/app.yaml" {
+		t.Errorf("got: %v", names)
+	}
+
+	// tarball with same image
+	r.Image.Tarball = true
+	if err = ImageRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	files, err := ReadOCILayout(r.ImageLayoutPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(files["index.json"]) != string(b) || files["oci-layout"] == nil {
+		t.Errorf("got: %s", files["index.json"])
+	}
+}
+
+func TestPushImage(t *testing.T) {
+	os.Setenv(DefaultOCIUsernameEnv, "user")
+	os.Setenv(DefaultOCIPasswordEnv, "secret")
+	defer os.Unsetenv(DefaultOCIUsernameEnv)
+	defer os.Unsetenv(DefaultOCIPasswordEnv)
+
+	r := imageRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	r.Image.Tarball = true
+	r.Image.Tags = []string{"{{.Version}}", "latest"}
+	if err := ImageRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	reg, srv := newFakeRegistry(t, false)
+	defer srv.Close()
+	host := strings.TrimPrefix(srv.URL, "http://")
+	r.Image.Repository = host + "/team/app"
+	r.Image.Insecure = true
+	if err := PushImage(context.Background(), r); err != nil {
+		t.Fatal(err)
+	}
+	index := reg.index(t, "latest")
+	if len(index.Manifests) != 2 || string(reg.manifests["v1.0.0"]) != string(reg.manifests["latest"]) {
+		t.Fatalf("got: %+v", index)
+	}
+	manifest := reg.manifest(t, index.Manifests[0].Digest)
+	if _, ok := reg.blobs[manifest.Layers[0].Digest]; !ok || manifest.Config.MediaType != MediaTypeOCIConfig {
+		t.Errorf("got: %+v", manifest)
+	}
+	// image of both tags is pushed once and every tag once
+	top := Digest(reg.manifests["latest"])
+	if reg.puts[top] != 1 || reg.puts["v1.0.0"] != 1 || reg.puts["latest"] != 1 {
+		t.Errorf("got: %v", reg.puts)
+	}
+
+	// ref names of layout win over tags
+	reg.puts = map[string]int{}
+	push := &Registry{Host: host, Repository: "team/app", Username: "user", Password: "secret", Insecure: true}
+	if err := PushOCILayout(context.Background(), push, r.ImageLayoutPath(), []string{"other"}); err != nil {
+		t.Fatal(err)
+	}
+	if reg.puts["other"] != 0 || reg.puts["v1.0.0"] != 1 || reg.puts["latest"] != 1 {
+		t.Errorf("got: %v", reg.puts)
+	}
+}
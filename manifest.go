@@ -0,0 +1,159 @@
+package gorelease
+
+import (
+	"encoding/json"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"time"
+)
+
+// ManifestName is a name of release manifest file
+const ManifestName = "release.json"
+
+// Manifest describes every published artifact of release
+type Manifest struct {
+	Version               string             `json:"version"`
+	Commit                string             `json:"commit,omitempty"`
+	Date                  time.Time          `json:"date"`
+	ChecksumsURL          string             `json:"checksums_url,omitempty"`
+	ChecksumsSignatureURL string             `json:"checksums_signature_url,omitempty"`
+	Artifacts             []ManifestArtifact `json:"artifacts"`
+}
+
+// ManifestArtifact describes artifact built for target and platform
+type ManifestArtifact struct {
+	Name            string       `json:"name"` // path relative to release dir
+	Type            ArtifactType `json:"type"`
+	Target          string       `json:"target"`
+	Os              string       `json:"os"`
+	Arch            string       `json:"arch"`
+	URL             string       `json:"url"`
+	Size            int64        `json:"size"`
+	SHA256          string       `json:"sha256"`
+	SignatureURL    string       `json:"signature_url,omitempty"`     // url of minisign signature
+	GPGSignatureURL string       `json:"gpg_signature_url,omitempty"` // url of gpg signature
+}
+
+// ManifestPath returns path of manifest file of prepared Release
+func (r *Release) ManifestPath() string {
+	return path.Join(r.Dir, ManifestName)
+}
+
+// MakeManifest creates Manifest of Release from urls of published files
+func MakeManifest(r *Release, urls Result) (*Manifest, error) {
+	expanded, err := expandTarget(r.Target, "", "")
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{
+		Version:   expanded.Version,
+		Commit:    r.Meta.Commit,
+		Date:      r.Meta.Date,
+		Artifacts: []ManifestArtifact{},
+	}
+	checksums := r.ChecksumPath()
+	m.ChecksumsURL = urls[checksums]
+	m.ChecksumsSignatureURL = signatureURL(urls, checksums, MinisignExt, GPGExt)
+
+	for _, a := range r.Artifacts() {
+		if a.Build == nil || a.Type == ArtifactSignature {
+			continue
+		}
+		info, err := os.Stat(a.Path)
+		if err != nil {
+			return nil, err
+		}
+		sum, err := FileChecksum(ChecksumSHA256, a.Path)
+		if err != nil {
+			return nil, err
+		}
+		m.Artifacts = append(m.Artifacts, ManifestArtifact{
+			Name:            a.Name(r),
+			Type:            a.Type,
+			Target:          a.Target,
+			Os:              a.Build.GOOS,
+			Arch:            a.Build.GOARCH,
+			URL:             urls[a.Path],
+			Size:            info.Size(),
+			SHA256:          sum,
+			SignatureURL:    urls[a.Path+MinisignExt],
+			GPGSignatureURL: urls[a.Path+GPGExt],
+		})
+	}
+	return m, nil
+}
+
+// WriteManifest writes Manifest to ManifestPath of Release
+func WriteManifest(r *Release, m *Manifest) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	log.Printf("writing manifest: %s", r.ManifestPath())
+	return ioutil.WriteFile(r.ManifestPath(), append(b, '\n'), 0644)
+}
+
+// ReadManifest reads Manifest from file
+func ReadManifest(file string) (*Manifest, error) {
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err = json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Artifact returns archive of target built for platform, or executable
+// if builds are not archived, nil if there is no such artifact
+func (m *Manifest) Artifact(target, goos, goarch string) *ManifestArtifact {
+	var found *ManifestArtifact
+	for i := range m.Artifacts {
+		a := &m.Artifacts[i]
+		if a.Target != target || a.Os != goos || a.Arch != goarch {
+			continue
+		}
+		switch a.Type {
+		case ArtifactArchive:
+			return a
+		case ArtifactBinary:
+			found = a
+		}
+	}
+	return found
+}
+
+// releaseManifest returns manifest of published Release,
+// it's read from ManifestPath if release wasn't published in this process
+func releaseManifest(r *Release) (*Manifest, error) {
+	if r.Manifest != nil {
+		return r.Manifest, nil
+	}
+	m, err := ReadManifest(r.ManifestPath())
+	if err != nil {
+		return nil, errors.Wrap(err, "release is not published")
+	}
+	return m, nil
+}
+
+// writeReleaseFile writes generated file into release dir
+func writeReleaseFile(r *Release, name string, data []byte) error {
+	p := path.Join(r.Dir, name)
+	log.Printf("writing %s", p)
+	return ioutil.WriteFile(p, data, 0644)
+}
+
+// signatureURL returns url of first published signature of file
+func signatureURL(urls Result, file string, exts ...string) string {
+	for _, ext := range exts {
+		if u, ok := urls[file+ext]; ok {
+			return u
+		}
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package gorelease_test
+
+import (
+	. "github.com/bukowa/gorelease"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestMakeManifest(t *testing.T) {
+	r := checksumRelease(t, ChecksumSHA512)
+	defer os.RemoveAll(r.DestDir)
+	r.Meta.Commit = "0123456789abcdef"
+
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	var urls = make(Result)
+	for _, a := range r.Artifacts() {
+		urls[a.Path] = "https://example.com/" + a.Name(r)
+	}
+	urls[r.ChecksumPath()+MinisignExt] = "https://example.com/checksums.txt.minisig"
+
+	m, err := MakeManifest(r, urls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Version != "v1.0.0" || m.Commit != r.Meta.Commit {
+		t.Errorf("got: %v %v", m.Version, m.Commit)
+	}
+	if m.ChecksumsURL != "https://example.com/checksums.txt" || m.ChecksumsSignatureURL != "https://example.com/checksums.txt.minisig" {
+		t.Errorf("got: %v %v", m.ChecksumsURL, m.ChecksumsSignatureURL)
+	}
+	if len(m.Artifacts) != 2 {
+		t.Fatalf("got: %v artifacts want: 2", len(m.Artifacts))
+	}
+	want := ManifestArtifact{
+		Name:   "linux_amd64/app",
+		Type:   ArtifactBinary,
+		Target: "app",
+		Os:     "linux",
+		Arch:   "amd64",
+		URL:    "https://example.com/linux_amd64/app",
+		Size:   5,
+		// printf linux | sha256sum
+		SHA256: "caf90169eefa5f807d577486b9f795ab86ae2983c5c20806cff959117e90af18",
+	}
+	if !reflect.DeepEqual(m.Artifacts[0], want) {
+		t.Errorf("got: %+v want: %+v", m.Artifacts[0], want)
+	}
+
+	if err = WriteManifest(r, m); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadManifest(r.ManifestPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read.Artifacts, m.Artifacts) {
+		t.Errorf("got: %+v want: %+v", read.Artifacts, m.Artifacts)
+	}
+}
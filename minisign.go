@@ -0,0 +1,297 @@
+package gorelease
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/scrypt"
+	"io"
+	"strings"
+)
+
+// minisign algorithm identifiers
+var (
+	minisignAlgEd     = [2]byte{'E', 'd'} // legacy signature of whole file
+	minisignAlgHashed = [2]byte{'E', 'D'} // signature of blake2b-512 of file
+	minisignKdfScrypt = [2]byte{'S', 'c'}
+	minisignKdfNone   = [2]byte{0, 0}
+	minisignChkBlake2 = [2]byte{'B', '2'}
+)
+
+// default scrypt limits of minisign
+const (
+	MinisignOpsLimit = 33554432
+	MinisignMemLimit = 1073741824
+)
+
+var (
+	ErrorMinisignKey       = errors.New("malformed minisign key")
+	ErrorMinisignSignature = errors.New("malformed minisign signature")
+	ErrorMinisignPassword  = errors.New("wrong password for minisign key")
+	ErrorMinisignKeyID     = errors.New("signature was made with other key")
+	ErrorMinisignInvalid   = errors.New("invalid minisign signature")
+)
+
+// MinisignPublicKey is a minisign compatible ed25519 public key
+type MinisignPublicKey struct {
+	ID  [8]byte
+	Key ed25519.PublicKey
+}
+
+// MinisignPrivateKey is a minisign compatible ed25519 private key
+type MinisignPrivateKey struct {
+	ID  [8]byte
+	Key ed25519.PrivateKey
+}
+
+// GenerateMinisignKey creates new key pair
+func GenerateMinisignKey() (*MinisignPrivateKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	k := &MinisignPrivateKey{Key: priv}
+	if _, err = io.ReadFull(rand.Reader, k.ID[:]); err != nil {
+		return nil, err
+	}
+	return k, nil
+}
+
+// Public returns public key of private key
+func (k *MinisignPrivateKey) Public() *MinisignPublicKey {
+	return &MinisignPublicKey{ID: k.ID, Key: k.Key.Public().(ed25519.PublicKey)}
+}
+
+// ParseMinisignPublicKey parses public key file or its base64 encoded line
+func ParseMinisignPublicKey(b []byte) (*MinisignPublicKey, error) {
+	raw, err := decodeMinisignLine(b, 2+8+ed25519.PublicKeySize)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(raw[:2], minisignAlgEd[:]) {
+		return nil, ErrorMinisignKey
+	}
+	k := &MinisignPublicKey{Key: ed25519.PublicKey(raw[10:])}
+	copy(k.ID[:], raw[2:10])
+	return k, nil
+}
+
+// MarshalText encodes public key in format of minisign public key file
+func (k *MinisignPublicKey) MarshalText() ([]byte, error) {
+	raw := append(append(minisignAlgEd[:], k.ID[:]...), k.Key...)
+	return []byte(fmt.Sprintf("untrusted comment: minisign public key %X\n%s\n",
+		binary.LittleEndian.Uint64(k.ID[:]), base64.StdEncoding.EncodeToString(raw))), nil
+}
+
+// ParseMinisignPrivateKey parses secret key file, password is used
+// only if key is encrypted
+func ParseMinisignPrivateKey(b []byte, password string) (*MinisignPrivateKey, error) {
+	const keynumLen = 8 + ed25519.PrivateKeySize + blake2b.Size256
+	raw, err := decodeMinisignLine(b, 2+2+2+32+8+8+keynumLen)
+	if err != nil {
+		return nil, err
+	}
+	var sigAlg, kdfAlg, chkAlg [2]byte
+	copy(sigAlg[:], raw[0:2])
+	copy(kdfAlg[:], raw[2:4])
+	copy(chkAlg[:], raw[4:6])
+	salt := raw[6:38]
+	ops := binary.LittleEndian.Uint64(raw[38:46])
+	mem := binary.LittleEndian.Uint64(raw[46:54])
+	keynum := append([]byte(nil), raw[54:]...)
+
+	if sigAlg != minisignAlgEd || chkAlg != minisignChkBlake2 {
+		return nil, ErrorMinisignKey
+	}
+	switch kdfAlg {
+	case minisignKdfNone:
+	case minisignKdfScrypt:
+		stream, err := minisignScrypt(password, salt, ops, mem, keynumLen)
+		if err != nil {
+			return nil, err
+		}
+		for i := range keynum {
+			keynum[i] ^= stream[i]
+		}
+	default:
+		return nil, ErrorMinisignKey
+	}
+
+	k := &MinisignPrivateKey{Key: ed25519.PrivateKey(keynum[8 : 8+ed25519.PrivateKeySize])}
+	copy(k.ID[:], keynum[:8])
+	if sum := minisignKeyChecksum(k); !bytes.Equal(sum, keynum[8+ed25519.PrivateKeySize:]) {
+		if kdfAlg == minisignKdfScrypt {
+			return nil, ErrorMinisignPassword
+		}
+		return nil, ErrorMinisignKey
+	}
+	return k, nil
+}
+
+// MarshalMinisignPrivateKey encodes key in format of minisign secret key file,
+// key is encrypted with scrypt limits ops and mem unless password is empty
+func MarshalMinisignPrivateKey(k *MinisignPrivateKey, password string, ops, mem uint64) ([]byte, error) {
+	keynum := append(append(k.ID[:], k.Key...), minisignKeyChecksum(k)...)
+	salt := make([]byte, 32)
+	kdf := minisignKdfNone
+	if password != "" {
+		kdf = minisignKdfScrypt
+		if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+			return nil, err
+		}
+		stream, err := minisignScrypt(password, salt, ops, mem, len(keynum))
+		if err != nil {
+			return nil, err
+		}
+		for i := range keynum {
+			keynum[i] ^= stream[i]
+		}
+	} else {
+		ops, mem = 0, 0
+	}
+
+	var buf bytes.Buffer
+	buf.Write(minisignAlgEd[:])
+	buf.Write(kdf[:])
+	buf.Write(minisignChkBlake2[:])
+	buf.Write(salt)
+	_ = binary.Write(&buf, binary.LittleEndian, ops)
+	_ = binary.Write(&buf, binary.LittleEndian, mem)
+	buf.Write(keynum)
+
+	comment := "minisign unencrypted secret key"
+	if password != "" {
+		comment = "minisign encrypted secret key"
+	}
+	return []byte(fmt.Sprintf("untrusted comment: %s\n%s\n", comment, base64.StdEncoding.EncodeToString(buf.Bytes()))), nil
+}
+
+// MinisignSign signs message and returns content of .minisig file,
+// trustedComment is signed together with signature
+func MinisignSign(k *MinisignPrivateKey, message io.Reader, trustedComment string) ([]byte, error) {
+	h, _ := blake2b.New512(nil)
+	if _, err := io.Copy(h, message); err != nil {
+		return nil, err
+	}
+	sig := ed25519.Sign(k.Key, h.Sum(nil))
+	global := ed25519.Sign(k.Key, append(append([]byte(nil), sig...), trustedComment...))
+
+	raw := append(append(minisignAlgHashed[:], k.ID[:]...), sig...)
+	return []byte(fmt.Sprintf("untrusted comment: signature from gorelease minisign secret key\n%s\ntrusted comment: %s\n%s\n",
+		base64.StdEncoding.EncodeToString(raw), trustedComment, base64.StdEncoding.EncodeToString(global))), nil
+}
+
+// MinisignVerify verifies .minisig signature of message
+// and returns its trusted comment
+func MinisignVerify(k *MinisignPublicKey, message io.Reader, signature []byte) (string, error) {
+	lines := strings.Split(strings.TrimSpace(strings.Replace(string(signature), "\r\n", "\n", -1)), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[2], "trusted comment: ") {
+		return "", ErrorMinisignSignature
+	}
+	raw, err := base64.StdEncoding.DecodeString(lines[1])
+	if err != nil || len(raw) != 2+8+ed25519.SignatureSize {
+		return "", ErrorMinisignSignature
+	}
+	global, err := base64.StdEncoding.DecodeString(lines[3])
+	if err != nil || len(global) != ed25519.SignatureSize {
+		return "", ErrorMinisignSignature
+	}
+	if !bytes.Equal(raw[2:10], k.ID[:]) {
+		return "", ErrorMinisignKeyID
+	}
+
+	var msg []byte
+	switch {
+	case bytes.Equal(raw[:2], minisignAlgHashed[:]):
+		h, _ := blake2b.New512(nil)
+		if _, err = io.Copy(h, message); err != nil {
+			return "", err
+		}
+		msg = h.Sum(nil)
+	case bytes.Equal(raw[:2], minisignAlgEd[:]):
+		var buf bytes.Buffer
+		if _, err = io.Copy(&buf, message); err != nil {
+			return "", err
+		}
+		msg = buf.Bytes()
+	default:
+		return "", ErrorMinisignSignature
+	}
+
+	sig := raw[10:]
+	comment := strings.TrimPrefix(lines[2], "trusted comment: ")
+	if !ed25519.Verify(k.Key, msg, sig) {
+		return "", ErrorMinisignInvalid
+	}
+	if !ed25519.Verify(k.Key, append(append([]byte(nil), sig...), comment...), global) {
+		return "", ErrorMinisignInvalid
+	}
+	return comment, nil
+}
+
+// decodeMinisignLine decodes base64 line of minisign file
+// skipping untrusted comment
+func decodeMinisignLine(b []byte, size int) ([]byte, error) {
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "untrusted comment:") {
+			continue
+		}
+		raw, err := base64.StdEncoding.DecodeString(line)
+		if err != nil || len(raw) != size {
+			return nil, ErrorMinisignKey
+		}
+		return raw, nil
+	}
+	return nil, ErrorMinisignKey
+}
+
+func minisignKeyChecksum(k *MinisignPrivateKey) []byte {
+	h, _ := blake2b.New256(nil)
+	h.Write(minisignAlgEd[:])
+	h.Write(k.ID[:])
+	h.Write(k.Key)
+	return h.Sum(nil)
+}
+
+// minisignScrypt derives key stream like libsodium crypto_pwhash_scryptsalsa208sha256
+func minisignScrypt(password string, salt []byte, ops, mem uint64, size int) ([]byte, error) {
+	n, r, p := scryptParams(ops, mem)
+	return scrypt.Key([]byte(password), salt, n, r, p, size)
+}
+
+// scryptParams converts libsodium limits to scrypt parameters
+func scryptParams(ops, mem uint64) (n, r, p int) {
+	if ops < 32768 {
+		ops = 32768
+	}
+	r = 8
+	var nLog2 uint
+	if ops < mem/32 {
+		p = 1
+		maxN := ops / uint64(r*4)
+		for nLog2 = 1; nLog2 < 63; nLog2++ {
+			if uint64(1)<<nLog2 > maxN/2 {
+				break
+			}
+		}
+	} else {
+		maxN := mem / uint64(r*128)
+		for nLog2 = 1; nLog2 < 63; nLog2++ {
+			if uint64(1)<<nLog2 > maxN/2 {
+				break
+			}
+		}
+		maxrp := (ops / 4) / (uint64(1) << nLog2)
+		if maxrp > 0x3fffffff {
+			maxrp = 0x3fffffff
+		}
+		p = int(maxrp) / r
+	}
+	return 1 << nLog2, r, p
+}
@@ -0,0 +1,98 @@
+package gorelease_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMinisign_keys(t *testing.T) {
+	k, err := GenerateMinisignKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MarshalMinisignPrivateKey(k, "secret", 32768, 16777216)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = ParseMinisignPrivateKey(b, "wrong"); !errors.Is(err, ErrorMinisignPassword) {
+		t.Errorf("want wrong password got: %v", err)
+	}
+	parsed, err := ParseMinisignPrivateKey(b, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsed.Key, k.Key) || parsed.ID != k.ID {
+		t.Error("parsed key differs")
+	}
+
+	pub, err := k.Public().MarshalText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsedPub, err := ParseMinisignPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(parsedPub.Key, k.Public().Key) {
+		t.Error("parsed public key differs")
+	}
+}
+
+func TestSignRelease_minisign(t *testing.T) {
+	k, err := GenerateMinisignKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := MarshalMinisignPrivateKey(k, "", 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Setenv(DefaultMinisignKeyEnv, string(b))
+	defer os.Unsetenv(DefaultMinisignKeyEnv)
+
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	r.Sign.Minisign = &Minisign{}
+
+	if err = ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	if err = SignRelease(r); err != nil {
+		t.Fatal(err)
+	}
+
+	var sigs int
+	for _, a := range r.Artifacts() {
+		if a.Type != ArtifactSignature {
+			continue
+		}
+		sigs++
+		if _, err = os.Stat(a.Path); err != nil {
+			t.Error(err)
+		}
+	}
+	if sigs != 3 {
+		t.Errorf("got: %v signatures want: 3", sigs)
+	}
+
+	ctx := context.Background()
+	if err = VerifyMinisignRelease(ctx, k.Public(), DefaultChecksumName, DirOpener(r.Dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	// tamper with signed file
+	bin := filepath.Join(r.Dir, "linux_amd64", "app")
+	if err = ioutil.WriteFile(bin, []byte("tampered"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	err = VerifyMinisign(ctx, k.Public(), "linux_amd64/app", DirOpener(r.Dir))
+	if !errors.Is(err, ErrorMinisignInvalid) {
+		t.Errorf("want invalid signature got: %v", err)
+	}
+}
@@ -0,0 +1,203 @@
+package gorelease
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"path"
+	"strings"
+)
+
+// DefaultNixMessage is a default template of commit message of nix expression
+const DefaultNixMessage = "{{.Target}} {{.Version}}"
+
+// NixSystem maps platform of build to nix system
+var NixSystem = []struct{ goos, goarch, system string }{
+	{"linux", "amd64", "x86_64-linux"},
+	{"linux", "arm64", "aarch64-linux"},
+	{"linux", "386", "i686-linux"},
+	{"linux", "arm", "armv7l-linux"},
+	{"darwin", "amd64", "x86_64-darwin"},
+	{"darwin", "arm64", "aarch64-darwin"},
+}
+
+// Nix configures nix expression fetching prebuilt artifacts,
+// rendered from release manifest with builds of one target
+type Nix struct {
+	Target      string        `yaml:"target"`      // name of target, first target if empty
+	Name        string        `yaml:"name"`        // pname, name of target if empty
+	Description string        `yaml:"description"` // meta.description
+	Homepage    string        `yaml:"homepage"`    // meta.homepage
+	License     string        `yaml:"license"`     // attribute of lib.licenses, like mit
+	Install     string        `yaml:"install"`     // shell of installPhase, installs executable if empty
+	Flake       bool          `yaml:"flake"`       // write flake.nix with packages of default.nix
+	Dir         string        `yaml:"dir"`         // dir of files in repository, repository if empty
+	Repository  GitRepository `yaml:"repository"`  // files are written into release dir if dir is empty
+}
+
+var ErrorNixNoBuilds = errors.New("target has no linux or darwin artifacts")
+
+// NixRelease is a basic ReleaseFunc writing nix expression
+var NixRelease ReleaseFunc = func(release *Release) error {
+	return NixReleaseContext(context.Background(), release)
+}
+
+// NixReleaseContext is a basic ReleaseContextFunc writing default.nix, and flake.nix
+// if it's enabled, from manifest of published release, they're committed into
+// repository if it's configured or written into release dir otherwise
+var NixReleaseContext ReleaseContextFunc = func(ctx context.Context, release *Release) error {
+	n := release.Nix
+	if n == nil {
+		return nil
+	}
+	m, err := releaseManifest(release)
+	if err != nil {
+		return err
+	}
+	files, err := MakeNixExpression(release, m)
+	if err != nil {
+		return err
+	}
+	if n.Repository.Dir == "" {
+		for name, b := range files {
+			if err = writeReleaseFile(release, name, b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	message, err := commitMessage(release, n.packageName(release), n.Repository.Message, DefaultNixMessage)
+	if err != nil {
+		return err
+	}
+	paths := map[string][]byte{}
+	for name, b := range files {
+		paths[path.Join(n.Dir, name)] = b
+	}
+	return n.Repository.CommitFiles(ctx, paths, message)
+}
+
+func (n *Nix) packageName(r *Release) string {
+	if n.Name != "" {
+		return n.Name
+	}
+	return targetName(r, n.Target)
+}
+
+// MakeNixExpression renders default.nix, callable package fetching archive,
+// or executable if build is not archived, of current system with SRI hash
+// from Manifest, and flake.nix if it's enabled
+func MakeNixExpression(r *Release, m *Manifest) (map[string][]byte, error) {
+	n := r.Nix
+	target, err := r.TargetNamed(n.Target)
+	if err != nil {
+		return nil, err
+	}
+	bin := target.Name
+
+	var systems []string
+	var sources strings.Builder
+	var zip bool
+	for _, s := range NixSystem {
+		a := m.Artifact(target.Name, s.goos, s.goarch)
+		if a == nil {
+			continue
+		}
+		if a.URL == "" {
+			return nil, errors.Errorf("artifact %s has no url", a.Name)
+		}
+		hash, err := SRIHash(a.SHA256)
+		if err != nil {
+			return nil, errors.Wrapf(err, "artifact %s", a.Name)
+		}
+		systems = append(systems, nixString(s.system))
+		fmt.Fprintf(&sources, "    %s = {\n      url = %s;\n      hash = %s;\n", s.system, nixString(a.URL), nixString(hash))
+		if a.Type == ArtifactBinary {
+			sources.WriteString("      binary = true;\n")
+		}
+		sources.WriteString("    };\n")
+		zip = zip || archiveFormat(a.Name) == FormatZip
+	}
+	if len(systems) == 0 {
+		return nil, ErrorNixNoBuilds
+	}
+
+	install := n.Install
+	if install == "" {
+		// executable is fetched as src or unpacked into working dir
+		install = fmt.Sprintf(`install -Dm755 ${if source.binary or false then "$src" else %s} $out/bin/%s`,
+			nixString(bin), bin)
+	}
+	var inputs, unzip string
+	if zip {
+		inputs, unzip = ", unzip", "  nativeBuildInputs = [ unzip ];\n"
+	}
+
+	var b strings.Builder
+	b.WriteString("# This file was generated by gorelease. DO NOT EDIT.\n")
+	fmt.Fprintf(&b, "{ lib, stdenvNoCC, fetchurl%s }:\n\n", inputs)
+	fmt.Fprintf(&b, "let\n  sources = {\n%s  };\n", sources.String())
+	b.WriteString("  system = stdenvNoCC.hostPlatform.system;\n")
+	fmt.Fprintf(&b, "  source = sources.${system} or (throw \"%s: unsupported system ${system}\");\n", nixEscape(n.packageName(r)))
+	b.WriteString("in\nstdenvNoCC.mkDerivation {\n")
+	fmt.Fprintf(&b, "  pname = %s;\n  version = %s;\n\n", nixString(n.packageName(r)), nixString(strings.TrimPrefix(m.Version, "v")))
+	b.WriteString("  src = fetchurl { inherit (source) url hash; };\n")
+	b.WriteString("  sourceRoot = \".\";\n  dontUnpack = source.binary or false;\n")
+	b.WriteString(unzip)
+	fmt.Fprintf(&b, "\n  installPhase = ''\n    runHook preInstall\n%s    runHook postInstall\n  '';\n", indent(install, "    "))
+	b.WriteString("\n  meta = {\n")
+	if n.Description != "" {
+		fmt.Fprintf(&b, "    description = %s;\n", nixString(n.Description))
+	}
+	if n.Homepage != "" {
+		fmt.Fprintf(&b, "    homepage = %s;\n", nixString(n.Homepage))
+	}
+	if n.License != "" {
+		fmt.Fprintf(&b, "    license = lib.licenses.%s;\n", n.License)
+	}
+	fmt.Fprintf(&b, "    platforms = [ %s ];\n", strings.Join(systems, " "))
+	b.WriteString("    sourceProvenance = [ lib.sourceTypes.binaryNativeCode ];\n")
+	fmt.Fprintf(&b, "    mainProgram = %s;\n  };\n}\n", nixString(bin))
+	files := map[string][]byte{"default.nix": []byte(b.String())}
+
+	if n.Flake {
+		b.Reset()
+		b.WriteString("# This file was generated by gorelease. DO NOT EDIT.\n{\n")
+		if n.Description != "" {
+			fmt.Fprintf(&b, "  description = %s;\n", nixString(n.Description))
+		}
+		b.WriteString("  inputs.nixpkgs.url = \"github:NixOS/nixpkgs/nixos-unstable\";\n\n")
+		b.WriteString("  outputs = { self, nixpkgs }:\n    let\n")
+		fmt.Fprintf(&b, "      systems = [ %s ];\n", strings.Join(systems, " "))
+		b.WriteString("      forAllSystems = f: nixpkgs.lib.genAttrs systems (system: f nixpkgs.legacyPackages.${system});\n")
+		b.WriteString("    in\n    {\n")
+		b.WriteString("      packages = forAllSystems (pkgs: { default = pkgs.callPackage ./default.nix { }; });\n")
+		b.WriteString("    };\n}\n")
+		files["flake.nix"] = []byte(b.String())
+	}
+	return files, nil
+}
+
+// SRIHash returns subresource integrity hash, like nix uses, of hex encoded sha256
+func SRIHash(sha256 string) (string, error) {
+	sum, err := hex.DecodeString(sha256)
+	if err != nil {
+		return "", err
+	}
+	if len(sum) != 32 {
+		return "", errors.Errorf("invalid sha256 %s", sha256)
+	}
+	return "sha256-" + base64.StdEncoding.EncodeToString(sum), nil
+}
+
+// nixString returns double quoted nix string literal
+func nixString(s string) string {
+	return `"` + nixEscape(s) + `"`
+}
+
+// nixEscape escapes s for double quoted nix string
+func nixEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, `${`, `\${`, "\n", `\n`).Replace(s)
+}
@@ -0,0 +1,126 @@
+package gorelease_test
+
+import (
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestMakeNixExpression(t *testing.T) {
+	r := manifestRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	r.Nix = &Nix{
+		Description: `App "with" ${quotes}`,
+		Homepage:    "https://example.com",
+		License:     "mit",
+		Flake:       true,
+	}
+	files, err := MakeNixExpression(r, r.Manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, three := "sha256-qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqo=", "sha256-MzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzMzM="
+	want := `# This file was generated by gorelease. DO NOT EDIT.
+{ lib, stdenvNoCC, fetchurl }:
+
+let
+  sources = {
+    x86_64-linux = {
+      url = "https://example.com/v1.2.0/linux_amd64/app_v1.2.0_linux_amd64.tar.gz";
+      hash = "` + a + `";
+    };
+    aarch64-linux = {
+      url = "https://example.com/v1.2.0/linux_arm64/app_v1.2.0_linux_arm64.tar.gz";
+      hash = "` + a + `";
+    };
+    i686-linux = {
+      url = "https://example.com/v1.2.0/linux_386/app_v1.2.0_linux_386.tar.gz";
+      hash = "` + three + `";
+    };
+    x86_64-darwin = {
+      url = "https://example.com/v1.2.0/darwin_amd64/app";
+      hash = "` + a + `";
+      binary = true;
+    };
+    aarch64-darwin = {
+      url = "https://example.com/v1.2.0/darwin_arm64/app_v1.2.0_darwin_arm64.tar.gz";
+      hash = "` + a + `";
+    };
+  };
+  system = stdenvNoCC.hostPlatform.system;
+  source = sources.${system} or (throw "app: unsupported system ${system}");
+in
+stdenvNoCC.mkDerivation {
+  pname = "app";
+  version = "1.2.0";
+
+  src = fetchurl { inherit (source) url hash; };
+  sourceRoot = ".";
+  dontUnpack = source.binary or false;
+
+  installPhase = ''
+    runHook preInstall
+    install -Dm755 ${if source.binary or false then "$src" else "app"} $out/bin/app
+    runHook postInstall
+  '';
+
+  meta = {
+    description = "App \"with\" \${quotes}";
+    homepage = "https://example.com";
+    license = lib.licenses.mit;
+    platforms = [ "x86_64-linux" "aarch64-linux" "i686-linux" "x86_64-darwin" "aarch64-darwin" ];
+    sourceProvenance = [ lib.sourceTypes.binaryNativeCode ];
+    mainProgram = "app";
+  };
+}
+`
+	if got := string(files["default.nix"]); got != want {
+		t.Errorf("got:\n%s", got)
+	}
+	flake := string(files["flake.nix"])
+	if !strings.Contains(flake, "default = pkgs.callPackage ./default.nix { };") ||
+		!strings.Contains(flake, `systems = [ "x86_64-linux" "aarch64-linux" "i686-linux" "x86_64-darwin" "aarch64-darwin" ];`) {
+		t.Errorf("got:\n%s", flake)
+	}
+
+	r.Manifest.Artifacts[0].SHA256 = "abc"
+	if _, err = MakeNixExpression(r, r.Manifest); err == nil {
+		t.Error("expected error of invalid sha256")
+	}
+}
+
+func TestNixRelease(t *testing.T) {
+	r := manifestRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	r.Nix = &Nix{}
+	if err := NixRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ioutil.ReadFile(filepath.Join(r.Dir, "default.nix")); err != nil {
+		t.Error(err)
+	}
+	if _, err := os.Stat(filepath.Join(r.Dir, "flake.nix")); !os.IsNotExist(err) {
+		t.Errorf("got: %v", err)
+	}
+
+	checkout, bare := tapRepository(t, r.DestDir)
+	r.Nix = &Nix{
+		Flake:      true,
+		Dir:        "pkgs/app",
+		Repository: GitRepository{Dir: checkout, Remote: "origin", Branch: "main", Author: "Release Bot <bot@example.com>"},
+	}
+	if err := NixRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	if files := git(t, bare, "ls-tree", "-r", "--name-only", "main"); files != "pkgs/app/default.nix\npkgs/app/flake.nix\n" {
+		t.Errorf("got: %q", files)
+	}
+
+	r.Manifest.Artifacts = r.Manifest.Artifacts[5:]
+	if err := NixRelease(r); err != ErrorNixNoBuilds {
+		t.Errorf("got: %v", err)
+	}
+}
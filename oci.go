@@ -0,0 +1,108 @@
+package gorelease
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+// OCI media types
+const (
+	MediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	MediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	MediaTypeOCIConfig      = "application/vnd.oci.image.config.v1+json"
+	MediaTypeOCILayer       = "application/vnd.oci.image.layer.v1.tar"
+	MediaTypeOCILayerGzip   = "application/vnd.oci.image.layer.v1.tar+gzip"
+	MediaTypeArtifactConfig = "application/vnd.unknown.config.v1+json" // config of artifacts, like ORAS
+)
+
+// OCI annotations
+const (
+	AnnotationTitle    = "org.opencontainers.image.title"
+	AnnotationVersion  = "org.opencontainers.image.version"
+	AnnotationRevision = "org.opencontainers.image.revision"
+	AnnotationCreated  = "org.opencontainers.image.created"
+	AnnotationRefName  = "org.opencontainers.image.ref.name"
+)
+
+// OCIDescriptor describes content addressed blob
+type OCIDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Platform    *OCIPlatform      `json:"platform,omitempty"`
+}
+
+// OCIPlatform is platform of manifest in index
+type OCIPlatform struct {
+	Architecture string `json:"architecture"`
+	OS           string `json:"os"`
+	Variant      string `json:"variant,omitempty"`
+}
+
+// OCIManifest is OCI image manifest
+type OCIManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Config        OCIDescriptor     `json:"config"`
+	Layers        []OCIDescriptor   `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// OCIIndex is OCI image index
+type OCIIndex struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	Manifests     []OCIDescriptor   `json:"manifests"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// OCIBlob is blob with its descriptor
+type OCIBlob struct {
+	OCIDescriptor
+	Data []byte
+}
+
+// NewOCIBlob creates blob of data with media type
+func NewOCIBlob(mediaType string, data []byte) OCIBlob {
+	return OCIBlob{OCIDescriptor: OCIDescriptor{MediaType: mediaType, Digest: Digest(data), Size: int64(len(data))}, Data: data}
+}
+
+// NewOCIJSONBlob creates blob of JSON encoded v
+func NewOCIJSONBlob(mediaType string, v interface{}) (OCIBlob, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return OCIBlob{}, err
+	}
+	return NewOCIBlob(mediaType, b), nil
+}
+
+// Digest returns sha256 digest of data
+func Digest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+var invalidTagChars = regexp.MustCompile(`[^\w.-]`)
+
+// OCITag converts version into valid tag, invalid characters
+// like + of build metadata are replaced with _
+func OCITag(version string) string {
+	tag := invalidTagChars.ReplaceAllString(version, "_")
+	if len(tag) > 128 {
+		tag = tag[:128]
+	}
+	return strings.TrimLeft(tag, ".-")
+}
+
+// OCIPlatformOf returns platform of GOOS and GOARCH
+func OCIPlatformOf(goos, goarch string) *OCIPlatform {
+	p := &OCIPlatform{OS: goos, Architecture: goarch}
+	if goarch == "arm64" {
+		p.Variant = "v8"
+	}
+	return p
+}
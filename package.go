@@ -0,0 +1,430 @@
+package gorelease
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// supported package formats
+const (
+	FormatDeb = "deb"
+	FormatRPM = "rpm"
+	FormatAPK = "apk"
+)
+
+// DefaultPackageBinDir is a default dir of executable in packages
+const DefaultPackageBinDir = "/usr/bin"
+
+// types of package files
+const (
+	PackageFileConfig = "config" // kept when modified, like conffiles of deb
+	PackageFileDoc    = "doc"
+)
+
+// PackageArch maps GOARCH to architecture name of every package format,
+// arch of Package config takes precedence
+var PackageArch = map[string]map[string]string{
+	FormatDeb: {
+		"386": "i386", "amd64": "amd64", "arm": "armhf", "arm64": "arm64",
+		"mips64le": "mips64el", "mipsle": "mipsel", "ppc64le": "ppc64el",
+		"riscv64": "riscv64", "s390x": "s390x",
+	},
+	FormatRPM: {
+		"386": "i386", "amd64": "x86_64", "arm": "armv7hl", "arm64": "aarch64",
+		"mips64le": "mips64el", "mipsle": "mipsel", "ppc64le": "ppc64le",
+		"riscv64": "riscv64", "s390x": "s390x",
+	},
+	FormatAPK: {
+		"386": "x86", "amd64": "x86_64", "arm": "armv7", "arm64": "aarch64",
+		"ppc64le": "ppc64le", "riscv64": "riscv64", "s390x": "s390x",
+	},
+}
+
+// Package configures linux packages of FileBuilds made without
+// packaging tools, written next to executable of every linux build
+type Package struct {
+	Formats     []string `yaml:"formats"`     // deb, rpm and apk
+	Name        string   `yaml:"name"`        // name of package, name of target if empty
+	Description string   `yaml:"description"` // first line is summary
+	Maintainer  string   `yaml:"maintainer"`  // like Name <email@example.com>
+	Vendor      string   `yaml:"vendor"`
+	Homepage    string   `yaml:"homepage"`
+	License     string   `yaml:"license"`
+	Section     string   `yaml:"section"`  // section of deb and group of rpm
+	Priority    string   `yaml:"priority"` // priority of deb, optional if empty
+	Epoch       string   `yaml:"epoch"`    // epoch of deb and rpm
+	Release     string   `yaml:"release"`  // release of package, 1 for rpm and 0 for apk if empty
+	BinDir      string   `yaml:"bin_dir"`  // dir of executable, DefaultPackageBinDir if empty
+
+	PackageRelations `yaml:",inline"`
+
+	Files     []PackageFile                `yaml:"files"`     // configs, systemd units, docs
+	Scripts   PackageScripts               `yaml:"scripts"`   // maintainer scripts
+	Arch      map[string]map[string]string `yaml:"arch"`      // format: GOARCH: arch name
+	Overrides map[string]PackageRelations  `yaml:"overrides"` // relations per format
+}
+
+// PackageRelations are relations to other packages, like
+// libc6 or libc6 (>= 2.28)
+type PackageRelations struct {
+	Depends    []string `yaml:"depends"`
+	Recommends []string `yaml:"recommends"`
+	Conflicts  []string `yaml:"conflicts"`
+	Provides   []string `yaml:"provides"`
+	Replaces   []string `yaml:"replaces"`
+}
+
+// PackageFile is file installed by package
+type PackageFile struct {
+	Src  string      `yaml:"src"`  // path on disk
+	Dst  string      `yaml:"dst"`  // absolute path on target system
+	Type string      `yaml:"type"` // config, doc or empty
+	Mode os.FileMode `yaml:"mode"` // mode of file on disk if zero
+}
+
+// PackageScripts are paths of shell scripts run by package manager
+type PackageScripts struct {
+	PreInstall  string `yaml:"preinstall"`
+	PostInstall string `yaml:"postinstall"`
+	PreRemove   string `yaml:"preremove"`
+	PostRemove  string `yaml:"postremove"`
+}
+
+var (
+	ErrorUnknownPackageFormat = errors.New("unknown package format")
+	ErrorPackageVersion       = errors.New("version is not supported by package format")
+)
+
+// PackageError is returned when package can't be created
+type PackageError struct {
+	Path string // path of package
+	Err  error
+}
+
+func (e *PackageError) Error() string {
+	return fmt.Sprintf("while creating package %s: %s", e.Path, e.Err)
+}
+
+func (e *PackageError) Unwrap() error {
+	return e.Err
+}
+
+// ArchFor returns architecture name of goarch in package format
+func (p *Package) ArchFor(format, goarch string) string {
+	if a, ok := p.Arch[format][goarch]; ok {
+		return a
+	}
+	if a, ok := PackageArch[format][goarch]; ok {
+		return a
+	}
+	return goarch
+}
+
+// RelationsFor returns relations of package format,
+// non empty relations of overrides replace common ones
+func (p *Package) RelationsFor(format string) PackageRelations {
+	r := p.PackageRelations
+	o, ok := p.Overrides[format]
+	if !ok {
+		return r
+	}
+	if o.Depends != nil {
+		r.Depends = o.Depends
+	}
+	if o.Recommends != nil {
+		r.Recommends = o.Recommends
+	}
+	if o.Conflicts != nil {
+		r.Conflicts = o.Conflicts
+	}
+	if o.Provides != nil {
+		r.Provides = o.Provides
+	}
+	if o.Replaces != nil {
+		r.Replaces = o.Replaces
+	}
+	return r
+}
+
+// PackageRelease is a basic BuildReleaseFunc packaging
+// linux FileBuilds of targets with package config
+var PackageRelease BuildReleaseFunc = func(release *Release) error {
+	return PackageReleaseContext(context.Background(), release)
+}
+
+// PackageReleaseContext is a basic BuildReleaseContextFunc packaging
+// linux FileBuilds of targets with package config
+var PackageReleaseContext BuildReleaseContextFunc = func(ctx context.Context, release *Release) error {
+	return release.ForEachTargetBuild(func(t *Target, b *FileBuild) error {
+		for i, p := range b.PackagePaths {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			log.Printf("packaging: %s %s - %s", b.GOOS, b.GOARCH, p)
+			if err := MakePackage(*t, b, t.Package.Formats[i], p); err != nil {
+				return &PackageError{Path: p, Err: err}
+			}
+		}
+		return nil
+	})
+}
+
+// packagePaths returns paths of packages for FileBuild of expanded Target
+func packagePaths(t Target, b FileBuild) ([]string, error) {
+	if t.Package == nil || b.GOOS != "linux" {
+		return nil, nil
+	}
+	var paths []string
+	for _, format := range t.Package.Formats {
+		info, err := makePackageInfo(t, b, format)
+		if err != nil {
+			return nil, err
+		}
+		var name string
+		switch format {
+		case FormatDeb:
+			name = fmt.Sprintf("%s_%s_%s.deb", info.Name, info.Version, info.Arch)
+		case FormatRPM:
+			name = fmt.Sprintf("%s-%s-%s.%s.rpm", info.Name, info.Version, info.Release, info.Arch)
+		case FormatAPK:
+			name = fmt.Sprintf("%s_%s-r%s_%s.apk", info.Name, info.Version, info.Release, info.Arch)
+		default:
+			return nil, errors.Wrap(ErrorUnknownPackageFormat, format)
+		}
+		paths = append(paths, path.Join(path.Dir(b.BinPath), name))
+	}
+	return paths, nil
+}
+
+// packageInfo is package metadata resolved for format and FileBuild
+type packageInfo struct {
+	*Package
+	Name      string
+	Version   string // version in format of package manager
+	Release   string
+	Arch      string
+	Summary   string
+	Relations PackageRelations
+	Time      time.Time // of files, commit date
+	Entries   []packageEntry
+	Scripts   map[string]string // contents of scripts by PackageScripts yaml name
+}
+
+// packageEntry is file in package
+type packageEntry struct {
+	Dst  string // absolute path
+	Mode os.FileMode
+	Type string
+	Data []byte
+}
+
+func makePackageInfo(t Target, b FileBuild, format string) (*packageInfo, error) {
+	version, err := packageVersion(format, t.Version)
+	if err != nil {
+		return nil, err
+	}
+	p := t.Package
+	info := &packageInfo{
+		Package:   p,
+		Name:      p.Name,
+		Version:   version,
+		Release:   p.Release,
+		Arch:      p.ArchFor(format, b.GOARCH),
+		Relations: p.RelationsFor(format),
+		Time:      archiveTime(t.Meta),
+	}
+	if info.Name == "" {
+		info.Name = t.Name
+	}
+	if info.Release == "" {
+		switch format {
+		case FormatRPM:
+			info.Release = "1"
+		case FormatAPK:
+			info.Release = "0"
+		}
+	}
+	info.Summary = strings.TrimSpace(strings.SplitN(strings.TrimSpace(p.Description), "\n", 2)[0])
+	if info.Summary == "" {
+		info.Summary = info.Name
+	}
+	return info, nil
+}
+
+// packageVersion converts version like v1.2.3-rc.1+abc into version
+// accepted by package manager, pre-releases sort before release
+func packageVersion(format, version string) (string, error) {
+	v := strings.TrimPrefix(version, "v")
+	switch format {
+	case FormatDeb, FormatRPM:
+		return strings.Replace(v, "-", "~", -1), nil
+	case FormatAPK:
+		// apk allows only suffixes like _rc1 or _pre, and no build metadata
+		if i := strings.Index(v, "+"); i >= 0 {
+			v = v[:i]
+		}
+		// snapshot like 1.2.3-next or 1.2.3-rc.1.next is built
+		// from commits after its tag, so it's patch release of it
+		var patch string
+		if strings.HasSuffix(v, "-next") || strings.HasSuffix(v, ".next") {
+			v, patch = v[:len(v)-len("-next")], "_p0"
+		}
+		i := strings.Index(v, "-")
+		if i < 0 {
+			return v + patch, nil
+		}
+		pre := strings.ToLower(strings.Map(func(r rune) rune {
+			if r == '.' || r == '-' {
+				return -1
+			}
+			return r
+		}, v[i+1:]))
+		for _, suffix := range []string{"alpha", "beta", "pre", "rc"} {
+			n := strings.TrimPrefix(pre, suffix)
+			if len(n) < len(pre) && strings.Trim(n, "0123456789") == "" {
+				return v[:i] + "_" + pre + patch, nil
+			}
+		}
+		return "", errors.Wrapf(ErrorPackageVersion, "%s: %s", format, version)
+	}
+	return v, nil
+}
+
+// packageRelation is parsed relation like libc6 (>= 2.28)
+type packageRelation struct {
+	Name    string
+	Op      string // <, <=, =, >= or >
+	Version string
+}
+
+func parsePackageRelation(s string) packageRelation {
+	s = strings.NewReplacer("(", " ", ")", " ").Replace(s)
+	i := strings.IndexAny(s, "<>=")
+	if i < 0 {
+		return packageRelation{Name: strings.TrimSpace(s)}
+	}
+	rel := packageRelation{Name: strings.TrimSpace(s[:i])}
+	rest := s[i:]
+	j := strings.IndexFunc(rest, func(r rune) bool { return !strings.ContainsRune("<>=", r) })
+	if j < 0 {
+		j = len(rest)
+	}
+	rel.Op = strings.NewReplacer(">>", ">", "<<", "<").Replace(rest[:j])
+	rel.Version = strings.TrimSpace(rest[j:])
+	return rel
+}
+
+func parsePackageRelations(relations []string) []packageRelation {
+	var parsed []packageRelation
+	for _, s := range relations {
+		parsed = append(parsed, parsePackageRelation(s))
+	}
+	return parsed
+}
+
+// MakePackage creates package in format with executable
+// of FileBuild and files of package config
+func MakePackage(t Target, b *FileBuild, format, dst string) error {
+	info, err := makePackageInfo(t, *b, format)
+	if err != nil {
+		return err
+	}
+	if err = info.readFiles(b); err != nil {
+		return err
+	}
+	var write func(info *packageInfo) ([]byte, error)
+	switch format {
+	case FormatDeb:
+		write = makeDeb
+	case FormatRPM:
+		write = makeRPM
+	case FormatAPK:
+		write = makeAPK
+	default:
+		return errors.Wrap(ErrorUnknownPackageFormat, format)
+	}
+	data, err := write(info)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(dst, data, 0644)
+}
+
+// readFiles reads executable, files and scripts of package
+func (info *packageInfo) readFiles(b *FileBuild) error {
+	binDir := info.BinDir
+	if binDir == "" {
+		binDir = DefaultPackageBinDir
+	}
+	files := append([]PackageFile{{Src: b.BinPath, Dst: path.Join(binDir, b.Name), Mode: 0755}}, info.Files...)
+	for _, f := range files {
+		data, err := ioutil.ReadFile(f.Src)
+		if err != nil {
+			return err
+		}
+		mode := f.Mode
+		if mode == 0 {
+			st, err := os.Stat(f.Src)
+			if err != nil {
+				return err
+			}
+			mode = st.Mode().Perm()
+		}
+		switch f.Type {
+		case "", PackageFileConfig, PackageFileDoc:
+		default:
+			return errors.Errorf("unknown type %s of package file %s", f.Type, f.Dst)
+		}
+		info.Entries = append(info.Entries, packageEntry{Dst: path.Clean("/" + f.Dst), Mode: mode, Type: f.Type, Data: data})
+	}
+	sort.SliceStable(info.Entries, func(i, j int) bool { return info.Entries[i].Dst < info.Entries[j].Dst })
+
+	info.Scripts = map[string]string{}
+	for name, src := range map[string]string{
+		"preinstall":  info.Package.Scripts.PreInstall,
+		"postinstall": info.Package.Scripts.PostInstall,
+		"preremove":   info.Package.Scripts.PreRemove,
+		"postremove":  info.Package.Scripts.PostRemove,
+	} {
+		if src == "" {
+			continue
+		}
+		data, err := ioutil.ReadFile(src)
+		if err != nil {
+			return err
+		}
+		info.Scripts[name] = string(data)
+	}
+	return nil
+}
+
+// installedSize returns size of files in bytes
+func (info *packageInfo) installedSize() int64 {
+	var size int64
+	for _, e := range info.Entries {
+		size += int64(len(e.Data))
+	}
+	return size
+}
+
+// parentDirs returns sorted parent dirs of entries without leading slash
+func (info *packageInfo) parentDirs() []string {
+	seen := map[string]bool{}
+	var dirs []string
+	for _, e := range info.Entries {
+		for dir := path.Dir(e.Dst); dir != "/" && !seen[dir]; dir = path.Dir(dir) {
+			seen[dir] = true
+			dirs = append(dirs, strings.TrimPrefix(dir, "/"))
+		}
+	}
+	sort.Strings(dirs)
+	return dirs
+}
@@ -0,0 +1,84 @@
+package gorelease
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// apk scripts by PackageScripts yaml name
+var apkScripts = map[string]string{
+	"preinstall":  ".pre-install",
+	"postinstall": ".post-install",
+	"preremove":   ".pre-deinstall",
+	"postremove":  ".post-deinstall",
+}
+
+// makeAPK creates unsigned apk package, concatenated gzip streams
+// of control tar without end of archive and data tar
+func makeAPK(info *packageInfo) ([]byte, error) {
+	// data, files have checksums in pax headers like abuild-tar --hash
+	var entries []tarEntry
+	for _, dir := range info.parentDirs() {
+		entries = append(entries, tarEntry{Name: dir + "/", Mode: 0755, Dir: true})
+	}
+	for _, e := range info.Entries {
+		entries = append(entries, tarEntry{
+			Name: strings.TrimPrefix(e.Dst, "/"),
+			Mode: int64(e.Mode),
+			Data: e.Data,
+			PAX:  map[string]string{"APK-TOOLS.checksum.SHA1": fmt.Sprintf("%x", sha1.Sum(e.Data))},
+		})
+	}
+	data, err := makeTarGz(entries, info.Time, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// control
+	control := []tarEntry{{Name: ".PKGINFO", Mode: 0644, Data: []byte(apkPkgInfo(info, data))}}
+	for _, name := range []string{"preinstall", "postinstall", "preremove", "postremove"} {
+		if script, ok := info.Scripts[name]; ok {
+			control = append(control, tarEntry{Name: apkScripts[name], Mode: 0755, Data: []byte(script)})
+		}
+	}
+	controlTar, err := makeTarGz(control, info.Time, false)
+	if err != nil {
+		return nil, err
+	}
+	return append(controlTar, data...), nil
+}
+
+// apkPkgInfo returns .PKGINFO of apk package with hash of data
+func apkPkgInfo(info *packageInfo, data []byte) string {
+	var b strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s = %s\n", name, value)
+		}
+	}
+	relations := func(name, prefix string, rels []string) {
+		for _, r := range parsePackageRelations(rels) {
+			field(name, prefix+r.Name+r.Op+r.Version)
+		}
+	}
+	b.WriteString("# Generated by gorelease\n")
+	field("pkgname", info.Name)
+	field("pkgver", info.Version+"-r"+info.Release)
+	field("pkgdesc", info.Summary)
+	field("url", info.Homepage)
+	field("builddate", fmt.Sprint(info.Time.Unix()))
+	field("packager", info.Maintainer)
+	field("size", fmt.Sprint(info.installedSize()))
+	field("arch", info.Arch)
+	field("origin", info.Name)
+	field("maintainer", info.Maintainer)
+	field("license", info.License)
+	relations("depend", "", info.Relations.Depends)
+	relations("depend", "!", info.Relations.Conflicts)
+	relations("provides", "", info.Relations.Provides)
+	relations("replaces", "", info.Relations.Replaces)
+	field("datahash", fmt.Sprintf("%x", sha256.Sum256(data)))
+	return b.String()
+}
@@ -0,0 +1,196 @@
+package gorelease
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// deb maintainer scripts by PackageScripts yaml name
+var debScripts = map[string]string{
+	"preinstall":  "preinst",
+	"postinstall": "postinst",
+	"preremove":   "prerm",
+	"postremove":  "postrm",
+}
+
+// makeDeb creates ar archive with debian-binary, control.tar.gz and data.tar.gz
+func makeDeb(info *packageInfo) ([]byte, error) {
+	// data
+	var dirs []tarEntry
+	for _, dir := range info.parentDirs() {
+		dirs = append(dirs, tarEntry{Name: "./" + dir + "/", Mode: 0755, Dir: true})
+	}
+	var files []tarEntry
+	var md5sums, conffiles strings.Builder
+	for _, e := range info.Entries {
+		files = append(files, tarEntry{Name: "." + e.Dst, Mode: int64(e.Mode), Data: e.Data})
+		fmt.Fprintf(&md5sums, "%x  %s\n", md5.Sum(e.Data), strings.TrimPrefix(e.Dst, "/"))
+		if e.Type == PackageFileConfig {
+			conffiles.WriteString(e.Dst + "\n")
+		}
+	}
+	data, err := makeTarGz(append(append([]tarEntry{{Name: "./", Mode: 0755, Dir: true}}, dirs...), files...), info.Time, true)
+	if err != nil {
+		return nil, err
+	}
+
+	// control
+	control := []tarEntry{
+		{Name: "./control", Mode: 0644, Data: []byte(debControl(info))},
+		{Name: "./md5sums", Mode: 0644, Data: []byte(md5sums.String())},
+	}
+	if conffiles.Len() > 0 {
+		control = append(control, tarEntry{Name: "./conffiles", Mode: 0644, Data: []byte(conffiles.String())})
+	}
+	for _, name := range []string{"preinstall", "postinstall", "preremove", "postremove"} {
+		if script, ok := info.Scripts[name]; ok {
+			control = append(control, tarEntry{Name: "./" + debScripts[name], Mode: 0755, Data: []byte(script)})
+		}
+	}
+	controlTar, err := makeTarGz(append([]tarEntry{{Name: "./", Mode: 0755, Dir: true}}, control...), info.Time, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("!<arch>\n")
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{"debian-binary", []byte("2.0\n")},
+		{"control.tar.gz", controlTar},
+		{"data.tar.gz", data},
+	} {
+		fmt.Fprintf(&buf, "%-16s%-12d%-6d%-6d%-8s%-10d`\n", f.name, info.Time.Unix(), 0, 0, "100644", len(f.data))
+		buf.Write(f.data)
+		if len(f.data)%2 != 0 {
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// debControl returns control file of deb package
+func debControl(info *packageInfo) string {
+	version := info.Version
+	if info.Epoch != "" {
+		version = info.Epoch + ":" + version
+	}
+	if info.Release != "" {
+		version += "-" + info.Release
+	}
+	var b strings.Builder
+	field := func(name, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+	relations := func(name string, rels []string) {
+		var s []string
+		for _, r := range parsePackageRelations(rels) {
+			if r.Op == "" {
+				s = append(s, r.Name)
+				continue
+			}
+			op := r.Op
+			if op == ">" || op == "<" {
+				op += op
+			}
+			s = append(s, fmt.Sprintf("%s (%s %s)", r.Name, op, r.Version))
+		}
+		field(name, strings.Join(s, ", "))
+	}
+	field("Package", info.Name)
+	field("Version", version)
+	field("Section", info.Section)
+	priority := info.Priority
+	if priority == "" {
+		priority = "optional"
+	}
+	field("Priority", priority)
+	field("Architecture", info.Arch)
+	field("Maintainer", info.Maintainer)
+	field("Installed-Size", fmt.Sprint((info.installedSize()+1023)/1024))
+	relations("Depends", info.Relations.Depends)
+	relations("Recommends", info.Relations.Recommends)
+	relations("Conflicts", info.Relations.Conflicts)
+	relations("Provides", info.Relations.Provides)
+	relations("Replaces", info.Relations.Replaces)
+	field("Homepage", info.Homepage)
+
+	// extended description lines start with space, empty lines are dots
+	b.WriteString("Description: " + info.Summary + "\n")
+	lines := strings.Split(strings.TrimSpace(info.Description), "\n")
+	for _, line := range lines[1:] {
+		line = strings.TrimRight(line, " \t")
+		if line == "" {
+			line = "."
+		}
+		b.WriteString(" " + line + "\n")
+	}
+	return b.String()
+}
+
+// tarEntry is file or dir written by makeTarGz
+type tarEntry struct {
+	Name string
+	Mode int64
+	Dir  bool
+	Data []byte
+	PAX  map[string]string
+}
+
+// makeTarGz creates gzipped tar with files owned by root,
+// end of archive is omitted if closed is false
+func makeTarGz(entries []tarEntry, mtime time.Time, closed bool) ([]byte, error) {
+	var raw bytes.Buffer
+	tw := tar.NewWriter(&raw)
+	for _, e := range entries {
+		hdr := &tar.Header{
+			Typeflag:   tar.TypeReg,
+			Name:       e.Name,
+			Mode:       e.Mode,
+			Size:       int64(len(e.Data)),
+			ModTime:    mtime,
+			Uname:      "root",
+			Gname:      "root",
+			PAXRecords: e.PAX,
+		}
+		if e.Dir {
+			hdr.Typeflag = tar.TypeDir
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(e.Data); err != nil {
+			return nil, err
+		}
+	}
+	var err error
+	if closed {
+		err = tw.Close()
+	} else {
+		err = tw.Flush()
+	}
+	if err != nil {
+		return nil, err
+	}
+	var gz bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&gz, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = zw.Write(raw.Bytes()); err != nil {
+		return nil, err
+	}
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+	return gz.Bytes(), nil
+}
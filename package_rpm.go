@@ -0,0 +1,397 @@
+package gorelease
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"github.com/pkg/errors"
+	"path"
+	"sort"
+	"strings"
+)
+
+// rpm header tags
+const (
+	rpmTagHeaderSignatures  = 62
+	rpmTagHeaderImmutable   = 63
+	rpmTagHeaderI18NTable   = 100
+	rpmSigTagSHA1           = 269
+	rpmSigTagSHA256         = 273
+	rpmSigTagSize           = 1000
+	rpmSigTagMD5            = 1004
+	rpmSigTagPayloadSize    = 1007
+	rpmTagName              = 1000
+	rpmTagVersion           = 1001
+	rpmTagRelease           = 1002
+	rpmTagEpoch             = 1003
+	rpmTagSummary           = 1004
+	rpmTagDescription       = 1005
+	rpmTagBuildTime         = 1006
+	rpmTagBuildHost         = 1007
+	rpmTagSize              = 1009
+	rpmTagVendor            = 1011
+	rpmTagLicense           = 1014
+	rpmTagPackager          = 1015
+	rpmTagGroup             = 1016
+	rpmTagURL               = 1020
+	rpmTagOS                = 1021
+	rpmTagArch              = 1022
+	rpmTagPreIn             = 1023
+	rpmTagPostIn            = 1024
+	rpmTagPreUn             = 1025
+	rpmTagPostUn            = 1026
+	rpmTagFileSizes         = 1028
+	rpmTagFileModes         = 1030
+	rpmTagFileRDevs         = 1033
+	rpmTagFileMTimes        = 1034
+	rpmTagFileDigests       = 1035
+	rpmTagFileLinkTos       = 1036
+	rpmTagFileFlags         = 1037
+	rpmTagFileUserName      = 1039
+	rpmTagFileGroupName     = 1040
+	rpmTagSourceRPM         = 1044
+	rpmTagProvideName       = 1047
+	rpmTagRequireFlags      = 1048
+	rpmTagRequireName       = 1049
+	rpmTagRequireVersion    = 1050
+	rpmTagConflictFlags     = 1053
+	rpmTagConflictName      = 1054
+	rpmTagConflictVersion   = 1055
+	rpmTagPreInProg         = 1085
+	rpmTagPostInProg        = 1086
+	rpmTagPreUnProg         = 1087
+	rpmTagPostUnProg        = 1088
+	rpmTagObsoleteName      = 1090
+	rpmTagFileDevices       = 1095
+	rpmTagFileInodes        = 1096
+	rpmTagFileLangs         = 1097
+	rpmTagProvideFlags      = 1112
+	rpmTagProvideVersion    = 1113
+	rpmTagObsoleteFlags     = 1114
+	rpmTagObsoleteVersion   = 1115
+	rpmTagDirIndexes        = 1116
+	rpmTagBaseNames         = 1117
+	rpmTagDirNames          = 1118
+	rpmTagPayloadFormat     = 1124
+	rpmTagPayloadCompressor = 1125
+	rpmTagPayloadFlags      = 1126
+	rpmTagFileDigestAlgo    = 5011
+	rpmTagRecommendName     = 5046
+	rpmTagRecommendVersion  = 5047
+	rpmTagRecommendFlags    = 5048
+)
+
+// rpm header data types
+const (
+	rpmInt16       = 3
+	rpmInt32       = 4
+	rpmString      = 6
+	rpmBin         = 7
+	rpmStringArray = 8
+	rpmI18NString  = 9
+)
+
+// rpm flags of files and relations
+const (
+	rpmFileConfig    = 1 << 0
+	rpmFileDoc       = 1 << 1
+	rpmFileNoReplace = 1 << 4
+	rpmSenseLess     = 1 << 1
+	rpmSenseGreater  = 1 << 2
+	rpmSenseEqual    = 1 << 3
+	rpmSenseRPMLib   = 1 << 24
+	rpmDigestSHA256  = 8
+)
+
+// rpm scripts by PackageScripts yaml name
+var rpmScripts = map[string][2]int{
+	"preinstall":  {rpmTagPreIn, rpmTagPreInProg},
+	"postinstall": {rpmTagPostIn, rpmTagPostInProg},
+	"preremove":   {rpmTagPreUn, rpmTagPreUnProg},
+	"postremove":  {rpmTagPostUn, rpmTagPostUnProg},
+}
+
+// rpmHeader is rpm header structure, entries are written sorted by tag
+type rpmHeader map[int]rpmValue
+
+type rpmValue struct {
+	typ   int
+	count int
+	data  []byte
+}
+
+func (h rpmHeader) strings(tag, typ int, values ...string) {
+	var b []byte
+	for _, v := range values {
+		b = append(append(b, v...), 0)
+	}
+	h[tag] = rpmValue{typ: typ, count: len(values), data: b}
+}
+
+func (h rpmHeader) string(tag int, value string) {
+	h.strings(tag, rpmString, value)
+}
+
+func (h rpmHeader) int32(tag int, values ...int32) {
+	b := make([]byte, 4*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint32(b[4*i:], uint32(v))
+	}
+	h[tag] = rpmValue{typ: rpmInt32, count: len(values), data: b}
+}
+
+func (h rpmHeader) int16(tag int, values ...int16) {
+	b := make([]byte, 2*len(values))
+	for i, v := range values {
+		binary.BigEndian.PutUint16(b[2*i:], uint16(v))
+	}
+	h[tag] = rpmValue{typ: rpmInt16, count: len(values), data: b}
+}
+
+// bytes returns header with region of every entry under regionTag
+func (h rpmHeader) bytes(regionTag int) []byte {
+	var tags []int
+	for tag := range h {
+		tags = append(tags, tag)
+	}
+	sort.Ints(tags)
+
+	var index, store bytes.Buffer
+	entry := func(tag, typ, offset, count int) {
+		_ = binary.Write(&index, binary.BigEndian, [4]int32{int32(tag), int32(typ), int32(offset), int32(count)})
+	}
+	n := len(tags) + 1
+	for _, tag := range tags {
+		v := h[tag]
+		align := map[int]int{rpmInt16: 2, rpmInt32: 4}[v.typ]
+		for align > 0 && store.Len()%align != 0 {
+			store.WriteByte(0)
+		}
+		entry(tag, v.typ, store.Len(), v.count)
+		store.Write(v.data)
+	}
+	// region trailer is entry pointing back at start of index
+	trailer := store.Len()
+	_ = binary.Write(&store, binary.BigEndian, [4]int32{int32(regionTag), rpmBin, int32(-16 * n), 16})
+
+	var buf bytes.Buffer
+	buf.Write([]byte{0x8e, 0xad, 0xe8, 0x01, 0, 0, 0, 0})
+	_ = binary.Write(&buf, binary.BigEndian, [2]uint32{uint32(n), uint32(store.Len())})
+	_ = binary.Write(&buf, binary.BigEndian, [4]int32{int32(regionTag), rpmBin, int32(trailer), 16})
+	buf.Write(index.Bytes())
+	buf.Write(store.Bytes())
+	return buf.Bytes()
+}
+
+// relations sets name, flags and version tags of relations
+func (h rpmHeader) relations(tags [3]int, rels []packageRelation) {
+	if len(rels) == 0 {
+		return
+	}
+	var names, versions []string
+	var flags []int32
+	for _, r := range rels {
+		var f int32
+		if strings.Contains(r.Op, "<") {
+			f |= rpmSenseLess
+		}
+		if strings.Contains(r.Op, ">") {
+			f |= rpmSenseGreater
+		}
+		if strings.Contains(r.Op, "=") {
+			f |= rpmSenseEqual
+		}
+		if strings.HasPrefix(r.Name, "rpmlib(") {
+			f |= rpmSenseRPMLib
+		}
+		names, versions, flags = append(names, r.Name), append(versions, r.Version), append(flags, f)
+	}
+	h.strings(tags[0], rpmStringArray, names...)
+	h.int32(tags[1], flags...)
+	h.strings(tags[2], rpmStringArray, versions...)
+}
+
+// makeRPM creates rpm package with gzipped cpio payload
+func makeRPM(info *packageInfo) ([]byte, error) {
+	evr := info.Version + "-" + info.Release
+	if info.Epoch != "" {
+		evr = info.Epoch + ":" + evr
+	}
+	h := rpmHeader{}
+	h.strings(rpmTagHeaderI18NTable, rpmStringArray, "C")
+	h.string(rpmTagName, info.Name)
+	h.string(rpmTagVersion, info.Version)
+	h.string(rpmTagRelease, info.Release)
+	if info.Epoch != "" {
+		var epoch int32
+		if _, err := fmt.Sscan(info.Epoch, &epoch); err != nil {
+			return nil, errors.Errorf("epoch %q is not a number", info.Epoch)
+		}
+		h.int32(rpmTagEpoch, epoch)
+	}
+	h.strings(rpmTagSummary, rpmI18NString, info.Summary)
+	description := strings.TrimSpace(info.Description)
+	if description == "" {
+		description = info.Summary
+	}
+	h.strings(rpmTagDescription, rpmI18NString, description)
+	h.int32(rpmTagBuildTime, int32(info.Time.Unix()))
+	h.string(rpmTagBuildHost, "gorelease")
+	h.int32(rpmTagSize, int32(info.installedSize()))
+	for tag, value := range map[int]string{
+		rpmTagVendor:   info.Vendor,
+		rpmTagLicense:  info.License,
+		rpmTagPackager: info.Maintainer,
+		rpmTagURL:      info.Homepage,
+	} {
+		if value != "" {
+			h.string(tag, value)
+		}
+	}
+	group := info.Section
+	if group == "" {
+		group = "Unspecified"
+	}
+	h.strings(rpmTagGroup, rpmI18NString, group)
+	h.string(rpmTagOS, "linux")
+	h.string(rpmTagArch, info.Arch)
+	// binary packages are told from source packages by this tag
+	h.string(rpmTagSourceRPM, fmt.Sprintf("%s-%s-%s.src.rpm", info.Name, info.Version, info.Release))
+	for name, script := range info.Scripts {
+		h.string(rpmScripts[name][0], script)
+		h.string(rpmScripts[name][1], "/bin/sh")
+	}
+
+	// relations
+	requires := parsePackageRelations(info.Relations.Depends)
+	for _, lib := range [][2]string{
+		{"rpmlib(CompressedFileNames)", "3.0.4-1"},
+		{"rpmlib(FileDigests)", "4.6.0-1"},
+		{"rpmlib(PayloadFilesHavePrefix)", "4.0-1"},
+	} {
+		requires = append(requires, packageRelation{Name: lib[0], Op: "<=", Version: lib[1]})
+	}
+	h.relations([3]int{rpmTagRequireName, rpmTagRequireFlags, rpmTagRequireVersion}, requires)
+	provides := append(parsePackageRelations(info.Relations.Provides), packageRelation{Name: info.Name, Op: "=", Version: evr})
+	h.relations([3]int{rpmTagProvideName, rpmTagProvideFlags, rpmTagProvideVersion}, provides)
+	h.relations([3]int{rpmTagConflictName, rpmTagConflictFlags, rpmTagConflictVersion}, parsePackageRelations(info.Relations.Conflicts))
+	h.relations([3]int{rpmTagObsoleteName, rpmTagObsoleteFlags, rpmTagObsoleteVersion}, parsePackageRelations(info.Relations.Replaces))
+	h.relations([3]int{rpmTagRecommendName, rpmTagRecommendFlags, rpmTagRecommendVersion}, parsePackageRelations(info.Relations.Recommends))
+
+	// files, only files are owned, not system dirs
+	var dirs, names, digests, empty, users, langs []string
+	var sizes, mtimes, fileFlags, dirIndexes, devices, inodes []int32
+	var modes, rdevs []int16
+	dirIndex := map[string]int32{}
+	var payload bytes.Buffer
+	for i, e := range info.Entries {
+		dir := path.Dir(e.Dst) + "/"
+		if _, ok := dirIndex[dir]; !ok {
+			dirIndex[dir] = int32(len(dirs))
+			dirs = append(dirs, dir)
+		}
+		mode := 0100000 | int64(e.Mode.Perm())
+		var f int32
+		switch e.Type {
+		case PackageFileConfig:
+			f = rpmFileConfig | rpmFileNoReplace
+		case PackageFileDoc:
+			f = rpmFileDoc
+		}
+		names = append(names, path.Base(e.Dst))
+		dirIndexes = append(dirIndexes, dirIndex[dir])
+		digests = append(digests, fmt.Sprintf("%x", sha256.Sum256(e.Data)))
+		empty, users, langs = append(empty, ""), append(users, "root"), append(langs, "")
+		sizes = append(sizes, int32(len(e.Data)))
+		mtimes = append(mtimes, int32(info.Time.Unix()))
+		fileFlags = append(fileFlags, f)
+		devices, inodes = append(devices, 1), append(inodes, int32(i+1))
+		modes, rdevs = append(modes, int16(mode)), append(rdevs, 0)
+		writeCPIO(&payload, "."+e.Dst, i+1, mode, info.Time.Unix(), e.Data)
+	}
+	writeCPIO(&payload, "TRAILER!!!", 0, 0, 0, nil)
+	h.int32(rpmTagFileSizes, sizes...)
+	h.int16(rpmTagFileModes, modes...)
+	h.int16(rpmTagFileRDevs, rdevs...)
+	h.int32(rpmTagFileMTimes, mtimes...)
+	h.strings(rpmTagFileDigests, rpmStringArray, digests...)
+	h.strings(rpmTagFileLinkTos, rpmStringArray, empty...)
+	h.int32(rpmTagFileFlags, fileFlags...)
+	h.strings(rpmTagFileUserName, rpmStringArray, users...)
+	h.strings(rpmTagFileGroupName, rpmStringArray, users...)
+	h.int32(rpmTagFileDevices, devices...)
+	h.int32(rpmTagFileInodes, inodes...)
+	h.strings(rpmTagFileLangs, rpmStringArray, langs...)
+	h.int32(rpmTagDirIndexes, dirIndexes...)
+	h.strings(rpmTagBaseNames, rpmStringArray, names...)
+	h.strings(rpmTagDirNames, rpmStringArray, dirs...)
+	h.int32(rpmTagFileDigestAlgo, rpmDigestSHA256)
+	h.string(rpmTagPayloadFormat, "cpio")
+	h.string(rpmTagPayloadCompressor, "gzip")
+	h.string(rpmTagPayloadFlags, "9")
+
+	var gz bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&gz, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = zw.Write(payload.Bytes()); err != nil {
+		return nil, err
+	}
+	if err = zw.Close(); err != nil {
+		return nil, err
+	}
+	header := h.bytes(rpmTagHeaderImmutable)
+
+	// signature header with digests of header and payload
+	md5sum := md5.New()
+	md5sum.Write(header)
+	md5sum.Write(gz.Bytes())
+	sig := rpmHeader{}
+	sig.string(rpmSigTagSHA1, fmt.Sprintf("%x", sha1.Sum(header)))
+	sig.string(rpmSigTagSHA256, fmt.Sprintf("%x", sha256.Sum256(header)))
+	sig.int32(rpmSigTagSize, int32(len(header)+gz.Len()))
+	sig[rpmSigTagMD5] = rpmValue{typ: rpmBin, count: md5.Size, data: md5sum.Sum(nil)}
+	sig.int32(rpmSigTagPayloadSize, int32(payload.Len()))
+	signature := sig.bytes(rpmTagHeaderSignatures)
+
+	var buf bytes.Buffer
+	// lead
+	var lead [96]byte
+	copy(lead[:], []byte{0xed, 0xab, 0xee, 0xdb, 3, 0})
+	binary.BigEndian.PutUint16(lead[8:], 1) // arch
+	copy(lead[10:75], fmt.Sprintf("%s-%s-%s", info.Name, info.Version, info.Release))
+	binary.BigEndian.PutUint16(lead[76:], 1) // os
+	binary.BigEndian.PutUint16(lead[78:], 5) // signature type
+	buf.Write(lead[:])
+	buf.Write(signature)
+	for buf.Len()%8 != 0 {
+		buf.WriteByte(0)
+	}
+	buf.Write(header)
+	buf.Write(gz.Bytes())
+	return buf.Bytes(), nil
+}
+
+// writeCPIO writes file in cpio newc format
+func writeCPIO(w *bytes.Buffer, name string, ino int, mode, mtime int64, data []byte) {
+	nlink := 1
+	if ino == 0 {
+		nlink = 0
+	}
+	fmt.Fprintf(w, "070701%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x%08x",
+		ino, mode, 0, 0, nlink, mtime, len(data), 0, 0, 0, 0, len(name)+1, 0)
+	w.WriteString(name)
+	w.WriteByte(0)
+	for w.Len()%4 != 0 {
+		w.WriteByte(0)
+	}
+	w.Write(data)
+	for w.Len()%4 != 0 {
+		w.WriteByte(0)
+	}
+}
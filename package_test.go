@@ -0,0 +1,316 @@
+package gorelease_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	. "github.com/bukowa/gorelease"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func packageRelease(t *testing.T) *Release {
+	target := archiveTarget(t, FormatTarGz)
+	target.Archive = nil
+	target.Version = "v1.0.0-rc.1"
+	write := func(name, content string) string {
+		p := filepath.Join(target.DestDir, name)
+		if err := ioutil.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+	target.Package = &Package{
+		Formats:     []string{FormatDeb, FormatRPM, FormatAPK},
+		Description: "Example app\n\nLonger description.",
+		Maintainer:  "Jan Kowalski <jan@example.com>",
+		License:     "MIT",
+		PackageRelations: PackageRelations{
+			Depends: []string{"libc6 (>= 2.28)", "ca-certificates"},
+		},
+		Overrides: map[string]PackageRelations{
+			FormatRPM: {Depends: []string{"glibc >= 2.28"}},
+		},
+		Files: []PackageFile{
+			{Src: write("app.yaml", "port: 80"), Dst: "/etc/app/app.yaml", Type: PackageFileConfig},
+			{Src: write("app.service", "[Service]"), Dst: "/lib/systemd/system/app.service"},
+		},
+		Scripts: PackageScripts{PostInstall: write("postinstall.sh", "#!/bin/sh\nsystemctl daemon-reload\n")},
+	}
+	b, err := MakeFileBuild(target, "linux", "arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.MkdirAll(filepath.Dir(b.BinPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(b.BinPath, []byte("binary"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	target.FileBuilds = []FileBuild{b}
+	r := &Release{Target: target, Targets: []Target{target}}
+	r.Dir = filepath.Join(target.DestDir, target.Version)
+	return r
+}
+
+func TestPackageRelease(t *testing.T) {
+	r := packageRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	if err := PackageRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, a := range r.Artifacts() {
+		if a.Type == ArtifactPackage {
+			names = append(names, a.Name(r))
+		}
+	}
+	want := "linux_arm64/app_1.0.0~rc.1_arm64.deb linux_arm64/app-1.0.0~rc.1-1.aarch64.rpm linux_arm64/app_1.0.0_rc1-r0_aarch64.apk"
+	if strings.Join(names, " ") != want {
+		t.Fatalf("got: %v want: %v", names, want)
+	}
+	paths := r.Targets[0].FileBuilds[0].PackagePaths
+	t.Run("deb", func(t *testing.T) { testDeb(t, paths[0]) })
+	t.Run("rpm", func(t *testing.T) { testRPM(t, paths[1]) })
+	t.Run("apk", func(t *testing.T) { testAPK(t, paths[2]) })
+}
+
+func TestPackageRelease_version(t *testing.T) {
+	r := packageRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	target := r.Targets[0]
+
+	// every hyphen of pre-release sorts before release
+	target.Version = "v1.0.0-rc-1"
+	b, err := MakeFileBuild(target, "linux", "arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "app_1.0.0~rc~1_arm64.deb app-1.0.0~rc~1-1.aarch64.rpm app_1.0.0_rc1-r0_aarch64.apk"
+	var names []string
+	for _, p := range b.PackagePaths {
+		names = append(names, filepath.Base(p))
+	}
+	if strings.Join(names, " ") != want {
+		t.Errorf("got: %v want: %v", names, want)
+	}
+
+	// snapshot of untagged commit is patch release of its tag
+	for _, c := range []struct{ tag, want string }{
+		{"v1.2.3", "app_1.2.3_p0-r0_aarch64.apk"},
+		{"v1.2.3-rc.1", "app_1.2.3_rc1_p0-r0_aarch64.apk"},
+	} {
+		target.Version = SnapshotVersion(c.tag, "abc1234")
+		b, err := MakeFileBuild(target, "linux", "arm64")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := filepath.Base(b.PackagePaths[2]); got != c.want {
+			t.Errorf("%s got: %v want: %v", target.Version, got, c.want)
+		}
+	}
+
+	// apk has no suffix of unknown pre-release
+	for _, v := range []string{"v1.0.0-nightly", "v1.0.0-rc.1.fix"} {
+		target.Version = v
+		if _, err = MakeFileBuild(target, "linux", "arm64"); !errors.Is(err, ErrorPackageVersion) {
+			t.Errorf("%s got: %v", v, err)
+		}
+	}
+}
+
+// tarFiles returns regular files of gzipped tar
+func tarFiles(t *testing.T, r io.Reader) map[string]string {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr.Multistream(false)
+	files := map[string]string{}
+	tr := tar.NewReader(zr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF || err == io.ErrUnexpectedEOF && hdr == nil {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := ioutil.ReadAll(tr)
+		files[hdr.Name] = string(b)
+	}
+	// rest of gzip stream
+	if _, err = io.Copy(ioutil.Discard, zr); err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func testDeb(t *testing.T, p string) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, []byte("!<arch>\n")) {
+		t.Fatalf("got: %q", b[:8])
+	}
+	members := map[string][]byte{}
+	for rest := b[8:]; len(rest) >= 60; {
+		var size int
+		fmt.Sscan(string(rest[48:58]), &size)
+		members[strings.TrimSpace(string(rest[:16]))] = rest[60 : 60+size]
+		rest = rest[60+size+size%2:]
+	}
+	control := tarFiles(t, bytes.NewReader(members["control.tar.gz"]))
+	for _, line := range []string{
+		"Package: app\n", "Version: 1.0.0~rc.1\n", "Architecture: arm64\n",
+		"Depends: libc6 (>= 2.28), ca-certificates\n",
+		"Description: Example app\n .\n Longer description.\n",
+	} {
+		if !strings.Contains(control["./control"], line) {
+			t.Errorf("control has no %q: %s", line, control["./control"])
+		}
+	}
+	if control["./conffiles"] != "/etc/app/app.yaml\n" || !strings.Contains(control["./postinst"], "daemon-reload") {
+		t.Errorf("got: %v", control)
+	}
+	data := tarFiles(t, bytes.NewReader(members["data.tar.gz"]))
+	if data["./usr/bin/app"] != "binary" || data["./etc/app/app.yaml"] != "port: 80" {
+		t.Errorf("got: %v", data)
+	}
+
+	if _, err := exec.LookPath("dpkg-deb"); err == nil {
+		out, err := exec.Command("dpkg-deb", "--info", p).CombinedOutput()
+		if err != nil || !strings.Contains(string(out), "Package: app") {
+			t.Errorf("dpkg-deb: %s %v", out, err)
+		}
+	}
+}
+
+// rpmHeader reads rpm header and returns string values of tags
+func readRPMHeader(t *testing.T, r *bytes.Reader) map[int][]string {
+	var head struct {
+		Magic  [8]byte
+		Count  uint32
+		Length uint32
+	}
+	if err := binary.Read(r, binary.BigEndian, &head); err != nil || head.Magic[0] != 0x8e {
+		t.Fatalf("got: %+v %v", head, err)
+	}
+	index := make([][4]int32, head.Count)
+	if err := binary.Read(r, binary.BigEndian, index); err != nil {
+		t.Fatal(err)
+	}
+	store := make([]byte, head.Length)
+	if _, err := io.ReadFull(r, store); err != nil {
+		t.Fatal(err)
+	}
+	values := map[int][]string{}
+	for _, e := range index {
+		tag, typ, offset, count := int(e[0]), e[1], e[2], int(e[3])
+		switch typ {
+		case 6, 8, 9: // strings
+			data := store[offset:]
+			for i := 0; i < count; i++ {
+				end := bytes.IndexByte(data, 0)
+				values[tag] = append(values[tag], string(data[:end]))
+				data = data[end+1:]
+			}
+		case 4:
+			for i := 0; i < count; i++ {
+				values[tag] = append(values[tag], fmt.Sprint(binary.BigEndian.Uint32(store[int(offset)+4*i:])))
+			}
+		case 7:
+			values[tag] = []string{string(store[offset : int(offset)+count])}
+		}
+	}
+	return values
+}
+
+func testRPM(t *testing.T, p string) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(b, []byte{0xed, 0xab, 0xee, 0xdb}) {
+		t.Fatal("no rpm lead")
+	}
+	r := bytes.NewReader(b[96:])
+	sig := readRPMHeader(t, r)
+	for (int(r.Size())-r.Len())%8 != 0 {
+		r.ReadByte()
+	}
+	rest := b[len(b)-r.Len():]
+	h := readRPMHeader(t, r)
+	payload := rest[len(rest)-r.Len():]
+
+	if sum := md5.Sum(rest); sig[1004][0] != string(sum[:]) || sig[1000][0] != fmt.Sprint(len(rest)) {
+		t.Errorf("wrong md5 or size of signature: %v", sig)
+	}
+	if sum := fmt.Sprintf("%x", sha256.Sum256(rest[:len(rest)-len(payload)])); sig[273][0] != sum {
+		t.Errorf("got sha256: %v want: %v", sig[273], sum)
+	}
+	for tag, want := range map[int]string{
+		1000: "app", 1001: "1.0.0~rc.1", 1002: "1", 1022: "aarch64", 1014: "MIT",
+		1049: "glibc rpmlib(CompressedFileNames) rpmlib(FileDigests) rpmlib(PayloadFilesHavePrefix)",
+		1050: "2.28 3.0.4-1 4.6.0-1 4.0-1",
+		1117: "app.yaml app.service app",
+		1118: "/etc/app/ /lib/systemd/system/ /usr/bin/",
+		1037: "17 0 0",
+	} {
+		if got := strings.Join(h[tag], " "); got != want {
+			t.Errorf("tag %d got: %v want: %v", tag, got, want)
+		}
+	}
+	if !strings.Contains(h[1024][0], "daemon-reload") {
+		t.Errorf("got postin: %v", h[1024])
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cpio, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(cpio, []byte("070701")) || !bytes.Contains(cpio, []byte("./usr/bin/app\x00")) || !bytes.Contains(cpio, []byte("TRAILER!!!")) {
+		t.Errorf("got payload: %q", cpio)
+	}
+}
+
+func testAPK(t *testing.T, p string) {
+	b, err := ioutil.ReadFile(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := bytes.NewReader(b)
+	control := tarFiles(t, r)
+	data := b[len(b)-r.Len():]
+	info := control[".PKGINFO"]
+	for _, line := range []string{
+		"pkgname = app\n", "pkgver = 1.0.0_rc1-r0\n", "arch = aarch64\n",
+		"depend = libc6>=2.28\n", "depend = ca-certificates\n",
+		fmt.Sprintf("datahash = %x\n", sha256.Sum256(data)),
+	} {
+		if !strings.Contains(info, line) {
+			t.Errorf(".PKGINFO has no %q: %s", line, info)
+		}
+	}
+	if !strings.Contains(control[".post-install"], "daemon-reload") {
+		t.Errorf("got: %v", control)
+	}
+	if files := tarFiles(t, bytes.NewReader(data)); files["usr/bin/app"] != "binary" {
+		t.Errorf("got: %v", files)
+	}
+}
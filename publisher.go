@@ -0,0 +1,188 @@
+package gorelease
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"io"
+	"log"
+	"sort"
+	"sync"
+)
+
+// local path: url
+type Result map[string]string
+
+// Publisher publishes release artifacts to a backend, Publisher implementing
+// io.Closer, like one holding connection, is closed by Publish once publishing
+// ends, even if it fails
+type Publisher interface {
+	// Name returns name of publisher used as type in config
+	Name() string
+	// Configure decodes config of publisher from release section of config
+	Configure(unmarshal func(interface{}) error) error
+	// Publish uploads artifact and returns its url
+	Publish(ctx context.Context, r *Release, a *Artifact) (string, error)
+	// Finalize is called once every artifact and manifest are published
+	Finalize(ctx context.Context, r *Release, m *Manifest) error
+}
+
+// PublisherFactory creates unconfigured Publisher
+type PublisherFactory func() Publisher
+
+var (
+	publishersMu sync.RWMutex
+	publishers   = make(map[string]PublisherFactory)
+)
+
+var ErrorUnknownPublisher = errors.New("unknown publisher")
+
+// RegisterPublisher makes publisher available by name,
+// it panics if publisher with same name is already registered
+func RegisterPublisher(name string, f PublisherFactory) {
+	publishersMu.Lock()
+	defer publishersMu.Unlock()
+	if _, ok := publishers[name]; ok {
+		panic(fmt.Sprintf("publisher %s is already registered", name))
+	}
+	publishers[name] = f
+}
+
+// NewPublisher creates registered publisher by name
+func NewPublisher(name string) (Publisher, error) {
+	publishersMu.RLock()
+	f, ok := publishers[name]
+	publishersMu.RUnlock()
+	if !ok {
+		return nil, errors.Wrap(ErrorUnknownPublisher, name)
+	}
+	return f(), nil
+}
+
+// Publishers returns sorted names of registered publishers
+func Publishers() []string {
+	publishersMu.RLock()
+	defer publishersMu.RUnlock()
+	var names []string
+	for name := range publishers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PublisherConfig is publisher in release section of config
+type PublisherConfig struct {
+	Type   string                 // name of registered publisher
+	Config map[string]interface{} // rest of keys passed to publisher
+}
+
+func (c *PublisherConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal(&c.Config); err != nil {
+		return err
+	}
+	typ, ok := c.Config["type"].(string)
+	if !ok || typ == "" {
+		return errors.New("publisher in release section has no type")
+	}
+	c.Type = typ
+	delete(c.Config, "type")
+	return nil
+}
+
+// Publisher creates and configures publisher
+func (c PublisherConfig) Publisher() (Publisher, error) {
+	p, err := NewPublisher(c.Type)
+	if err != nil {
+		return nil, err
+	}
+	b, err := yaml.Marshal(c.Config)
+	if err != nil {
+		return nil, err
+	}
+	err = p.Configure(func(v interface{}) error {
+		return yaml.UnmarshalStrict(b, v)
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "while configuring publisher %s", c.Type)
+	}
+	return p, nil
+}
+
+// Publish publishes every artifact of Release, then writes and publishes
+// manifest and finalizes Publisher, urls are stored in result
+func Publish(ctx context.Context, r *Release, p Publisher, result Result) (_ *Manifest, err error) {
+	if c, ok := p.(io.Closer); ok {
+		defer func() {
+			if cerr := c.Close(); err == nil && cerr != nil {
+				err = errors.Wrapf(cerr, "while closing publisher %s", p.Name())
+			}
+		}()
+	}
+	var publish = func(a *Artifact) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		log.Printf("publishing with %s: %s", p.Name(), a.Path)
+		url, err := p.Publish(ctx, r, a)
+		if err != nil {
+			return &ReleaseError{Path: a.Path, Err: err}
+		}
+		result[a.Path] = url
+		return nil
+	}
+
+	var manifest *Artifact
+	err = r.ForEachArtifact(func(a *Artifact) error {
+		if a.Type == ArtifactManifest {
+			// a is reused by next iterations
+			m := *a
+			manifest = &m
+			return nil
+		}
+		return publish(a)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// write and publish manifest of published files
+	m, err := MakeManifest(r, result)
+	if err != nil {
+		return nil, err
+	}
+	if err = WriteManifest(r, m); err != nil {
+		return nil, err
+	}
+	if err = publish(manifest); err != nil {
+		return nil, err
+	}
+	if err = p.Finalize(ctx, r, m); err != nil {
+		return nil, err
+	}
+	r.Manifest = m
+	return m, nil
+}
+
+// PublishRelease returns ReleaseContextFunc publishing with Publisher
+func PublishRelease(p Publisher, result Result) ReleaseContextFunc {
+	return func(ctx context.Context, r *Release) error {
+		_, err := Publish(ctx, r, p, result)
+		return err
+	}
+}
+
+// ReleaseError is returned when file can't be released
+type ReleaseError struct {
+	Path string // local path of released file
+	Err  error
+}
+
+func (e *ReleaseError) Error() string {
+	return fmt.Sprintf("while releasing %s: %s", e.Path, e.Err)
+}
+
+func (e *ReleaseError) Unwrap() error {
+	return e.Err
+}
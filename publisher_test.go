@@ -0,0 +1,145 @@
+package gorelease_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/bukowa/gorelease"
+	"gopkg.in/yaml.v2"
+	"os"
+	"testing"
+)
+
+// fakePublisher records published artifacts
+type fakePublisher struct {
+	URL string `yaml:"url"`
+
+	published []string
+	finalized *Manifest
+}
+
+func (p *fakePublisher) Name() string {
+	return "fake"
+}
+
+func (p *fakePublisher) Configure(unmarshal func(interface{}) error) error {
+	return unmarshal(p)
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	p.published = append(p.published, a.Name(r))
+	return p.URL + "/" + a.Name(r), nil
+}
+
+func (p *fakePublisher) Finalize(ctx context.Context, r *Release, m *Manifest) error {
+	p.finalized = m
+	return nil
+}
+
+func init() {
+	RegisterPublisher("fake", func() Publisher { return &fakePublisher{} })
+}
+
+func TestPublisherConfig(t *testing.T) {
+	var r Release
+	err := yaml.Unmarshal([]byte("release:\n  - type: fake\n    url: https://example.com\n  - type: gcs\n    bucket: b\n"), &r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Publishers) != 2 || r.Publishers[0].Type != "fake" || r.Publishers[1].Type != GCSPublisherName {
+		t.Fatalf("got: %+v", r.Publishers)
+	}
+	p, err := r.Publishers[0].Publisher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.(*fakePublisher).URL != "https://example.com" {
+		t.Errorf("got: %+v", p)
+	}
+	gcs, err := r.Publishers[1].Publisher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gcs.(*GCSPublisher).Bucket != "b" {
+		t.Errorf("got: %+v", gcs)
+	}
+
+	for _, c := range []string{
+		"release:\n  - url: https://example.com\n",
+		"release:\n  - type: nope\n",
+		"release:\n  - type: fake\n    unknown: 1\n",
+		"release:\n  - type: gcs\n",
+	} {
+		var r Release
+		if err := yaml.Unmarshal([]byte(c), &r); err != nil {
+			continue
+		}
+		if _, err := r.Publishers[0].Publisher(); err == nil {
+			t.Errorf("expected error for %q", c)
+		}
+	}
+
+	if _, err := NewPublisher("nope"); !errors.Is(err, ErrorUnknownPublisher) {
+		t.Errorf("got: %v", err)
+	}
+}
+
+func TestPublish(t *testing.T) {
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+
+	p := &fakePublisher{URL: "https://example.com"}
+	result := make(Result)
+	m, err := Publish(context.Background(), r, p, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"linux_amd64/app", "windows_amd64/app.exe", DefaultChecksumName, ManifestName}
+	if len(p.published) != len(want) {
+		t.Fatalf("got: %v want: %v", p.published, want)
+	}
+	for i := range want {
+		if p.published[i] != want[i] {
+			t.Errorf("got: %v want: %v", p.published, want)
+		}
+	}
+	if p.finalized != m || r.Manifest != m {
+		t.Errorf("manifest was not finalized")
+	}
+	if m.Artifacts[0].URL != "https://example.com/linux_amd64/app" || m.ChecksumsURL != "https://example.com/"+DefaultChecksumName {
+		t.Errorf("got: %+v", m)
+	}
+	if result[r.ManifestPath()] != "https://example.com/"+ManifestName {
+		t.Errorf("got: %v", result)
+	}
+}
+
+// closingPublisher fails to publish and records whether it's closed
+type closingPublisher struct {
+	fakePublisher
+	closed bool
+}
+
+func (p *closingPublisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	return "", errors.New("upload failed")
+}
+
+func (p *closingPublisher) Close() error {
+	p.closed = true
+	return nil
+}
+
+func TestPublish_close(t *testing.T) {
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+
+	p := &closingPublisher{}
+	if _, err := Publish(context.Background(), r, p, make(Result)); err == nil {
+		t.Fatal("expected error")
+	}
+	if !p.closed {
+		t.Error("publisher was not closed")
+	}
+}
@@ -0,0 +1,251 @@
+package gorelease
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Registry is client of OCI distribution (docker registry v2) API
+// pushing blobs and manifests to one repository
+type Registry struct {
+	Host       string // like registry.example.com:5000
+	Repository string // like team/app
+	Username   string
+	Password   string
+	Insecure   bool         // use plain http
+	Client     *http.Client // http.DefaultClient if nil
+
+	mu   sync.Mutex
+	auth string // Authorization header used after challenge
+}
+
+var ErrorReference = errors.New("malformed image reference")
+
+// ParseRegistryReference splits reference like registry.example.com/team/app
+// into Registry host and repository, docker.io is used for references without host
+func ParseRegistryReference(ref string) (host, repository string, err error) {
+	i := strings.Index(ref, "/")
+	if i < 0 {
+		return "registry-1.docker.io", "library/" + ref, nil
+	}
+	host, repository = ref[:i], ref[i+1:]
+	if !strings.ContainsAny(host, ".:") && host != "localhost" {
+		return "registry-1.docker.io", ref, nil
+	}
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	if repository == "" || strings.ContainsAny(repository, ":@") {
+		return "", "", errors.Wrap(ErrorReference, ref)
+	}
+	return host, repository, nil
+}
+
+// BlobURL returns url of blob with digest
+func (r *Registry) BlobURL(digest string) string {
+	return r.url("blobs/" + digest)
+}
+
+// ManifestURL returns url of manifest with tag or digest
+func (r *Registry) ManifestURL(ref string) string {
+	return r.url("manifests/" + ref)
+}
+
+// PushBlob uploads blob unless registry already has it
+func (r *Registry) PushBlob(ctx context.Context, b OCIBlob) error {
+	resp, err := r.do(ctx, http.MethodHead, r.BlobURL(b.Digest), nil, nil)
+	if err == nil {
+		resp.Body.Close()
+		return nil
+	}
+	if e, ok := err.(*HTTPError); !ok || e.StatusCode != http.StatusNotFound {
+		return err
+	}
+
+	// monolithic upload
+	resp, err = r.do(ctx, http.MethodPost, r.url("blobs/uploads/"), nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	loc, err := resp.Location()
+	if err != nil {
+		return errors.Wrap(err, "blob upload has no location")
+	}
+	q := loc.Query()
+	q.Set("digest", b.Digest)
+	loc.RawQuery = q.Encode()
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	resp, err = r.do(ctx, http.MethodPut, loc.String(), header, b.Data)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+// PushManifest uploads manifest or index blob with reference
+func (r *Registry) PushManifest(ctx context.Context, ref string, b OCIBlob) error {
+	resp, err := r.do(ctx, http.MethodPut, r.ManifestURL(ref), http.Header{"Content-Type": {b.MediaType}}, b.Data)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (r *Registry) url(p string) string {
+	scheme := "https"
+	if r.Insecure {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s/%s", scheme, r.Host, r.Repository, p)
+}
+
+// do sends request, on 401 it authenticates with challenge
+// of registry and retries the request
+func (r *Registry) do(ctx context.Context, method, u string, header http.Header, body []byte) (*http.Response, error) {
+	resp, err := r.send(ctx, method, u, header, body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		resp.Body.Close()
+		if err = r.authenticate(ctx, challenge); err != nil {
+			return nil, err
+		}
+		if resp, err = r.send(ctx, method, u, header, body); err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+		return nil, &HTTPError{Method: method, URL: u, StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+	}
+	return resp, nil
+}
+
+func (r *Registry) send(ctx context.Context, method, u string, header http.Header, body []byte) (*http.Response, error) {
+	req, err := http.NewRequest(method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = int64(len(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	r.mu.Lock()
+	if r.auth != "" {
+		req.Header.Set("Authorization", r.auth)
+	}
+	r.mu.Unlock()
+	return r.client().Do(req)
+}
+
+// authenticate answers Basic or Bearer challenge
+func (r *Registry) authenticate(ctx context.Context, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	switch strings.ToLower(scheme) {
+	case "basic":
+		if r.Username == "" {
+			return errors.New("registry requires basic auth, username is not set")
+		}
+		req := http.Request{Header: http.Header{}}
+		req.SetBasicAuth(r.Username, r.Password)
+		r.mu.Lock()
+		r.auth = req.Header.Get("Authorization")
+		r.mu.Unlock()
+		return nil
+	case "bearer":
+	default:
+		return errors.Errorf("unsupported registry auth challenge %q", challenge)
+	}
+
+	realm, err := url.Parse(params["realm"])
+	if err != nil || params["realm"] == "" {
+		return errors.Errorf("registry auth challenge has no realm %q", challenge)
+	}
+	q := realm.Query()
+	if s := params["service"]; s != "" {
+		q.Set("service", s)
+	}
+	// scope of challenge may allow only pull
+	q.Set("scope", fmt.Sprintf("repository:%s:pull,push", r.Repository))
+	realm.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	if r.Username != "" {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+	c := &httpClient{client: r.Client}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = c.do(req, &token); err != nil {
+		return errors.Wrap(err, "while getting registry token")
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	r.mu.Lock()
+	r.auth = "Bearer " + token.Token
+	r.mu.Unlock()
+	return nil
+}
+
+func (r *Registry) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return http.DefaultClient
+}
+
+// parseChallenge parses WWW-Authenticate header like
+// Bearer realm="https://auth.example.com/token",service="registry"
+func parseChallenge(s string) (string, map[string]string) {
+	params := map[string]string{}
+	s = strings.TrimSpace(s)
+	i := strings.Index(s, " ")
+	if i < 0 {
+		return s, params
+	}
+	scheme, rest := s[:i], s[i+1:]
+	for rest != "" {
+		rest = strings.TrimLeft(rest, " ,")
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = rest[eq+1:]
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				value, rest = rest[1:], ""
+			} else {
+				value, rest = rest[1:end+1], rest[end+2:]
+			}
+		} else if comma := strings.Index(rest, ","); comma >= 0 {
+			value, rest = rest[:comma], rest[comma:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+	}
+	return scheme, params
+}
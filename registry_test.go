@@ -0,0 +1,175 @@
+package gorelease_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeRegistry is in-process registry v2 with token auth,
+// or basic auth if basic is set, for user:secret
+type fakeRegistry struct {
+	mu        sync.Mutex
+	basic     bool
+	blobs     map[string][]byte
+	manifests map[string][]byte // by digest and tag
+	puts      map[string]int    // of manifests by reference
+	uploads   int
+}
+
+func newFakeRegistry(t *testing.T, basic bool) (*fakeRegistry, *httptest.Server) {
+	s := &fakeRegistry{basic: basic, blobs: map[string][]byte{}, manifests: map[string][]byte{}, puts: map[string]int{}}
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		user, pass, ok := r.BasicAuth()
+		validBasic := ok && user == "user" && pass == "secret"
+
+		if r.URL.Path == "/token" {
+			if !validBasic || !strings.Contains(r.URL.Query().Get("scope"), "push") {
+				http.Error(w, `{"errors":[{"code":"UNAUTHORIZED"}]}`, http.StatusUnauthorized)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]string{"token": "token"})
+			return
+		}
+		if s.basic && !validBasic || !s.basic && r.Header.Get("Authorization") != "Bearer token" {
+			if s.basic {
+				w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			} else {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test",scope="repository:team/app:pull"`, srv.URL))
+			}
+			http.Error(w, `{"errors":[{"code":"UNAUTHORIZED"}]}`, http.StatusUnauthorized)
+			return
+		}
+
+		p := r.URL.Path
+		switch {
+		case strings.Contains(p, "/blobs/uploads/") && r.Method == http.MethodPost:
+			s.uploads++
+			w.Header().Set("Location", fmt.Sprintf("%s%d?state=x", p, s.uploads))
+			w.WriteHeader(http.StatusAccepted)
+		case strings.Contains(p, "/blobs/uploads/") && r.Method == http.MethodPut:
+			b, _ := ioutil.ReadAll(r.Body)
+			if d := r.URL.Query().Get("digest"); d != Digest(b) || r.URL.Query().Get("state") != "x" {
+				http.Error(w, `{"errors":[{"code":"DIGEST_INVALID"}]}`, http.StatusBadRequest)
+				return
+			}
+			s.blobs[Digest(b)] = b
+			w.WriteHeader(http.StatusCreated)
+		case strings.Contains(p, "/blobs/"):
+			if _, ok := s.blobs[p[strings.LastIndex(p, "/")+1:]]; !ok {
+				w.WriteHeader(http.StatusNotFound)
+			}
+		case strings.Contains(p, "/manifests/") && r.Method == http.MethodPut:
+			b, _ := ioutil.ReadAll(r.Body)
+			var m struct {
+				MediaType string
+				Config    *OCIDescriptor
+				Layers    []OCIDescriptor
+				Manifests []OCIDescriptor
+			}
+			if err := json.Unmarshal(b, &m); err != nil || m.MediaType != r.Header.Get("Content-Type") {
+				http.Error(w, `{"errors":[{"code":"MANIFEST_INVALID"}]}`, http.StatusBadRequest)
+				return
+			}
+			for _, d := range append(m.Layers, m.Manifests...) {
+				_, blob := s.blobs[d.Digest]
+				_, manifest := s.manifests[d.Digest]
+				if !blob && !manifest {
+					http.Error(w, `{"errors":[{"code":"MANIFEST_BLOB_UNKNOWN"}]}`, http.StatusBadRequest)
+					return
+				}
+			}
+			s.manifests[Digest(b)] = b
+			s.manifests[p[strings.LastIndex(p, "/")+1:]] = b
+			s.puts[p[strings.LastIndex(p, "/")+1:]]++
+			w.WriteHeader(http.StatusCreated)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return s, srv
+}
+
+// index returns image index tagged with tag
+func (s *fakeRegistry) index(t *testing.T, tag string) OCIIndex {
+	var index OCIIndex
+	if err := json.Unmarshal(s.manifests[tag], &index); err != nil {
+		t.Fatal(err)
+	}
+	return index
+}
+
+func (s *fakeRegistry) manifest(t *testing.T, digest string) OCIManifest {
+	var m OCIManifest
+	if err := json.Unmarshal(s.manifests[digest], &m); err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestRegistry(t *testing.T) {
+	for _, basic := range []bool{false, true} {
+		reg, srv := newFakeRegistry(t, basic)
+		host := strings.TrimPrefix(srv.URL, "http://")
+		r := &Registry{Host: host, Repository: "team/app", Username: "user", Password: "secret", Insecure: true}
+
+		blob := NewOCIBlob(MediaTypeOCILayer, []byte("layer"))
+		if err := r.PushBlob(context.Background(), blob); err != nil {
+			t.Fatal(err)
+		}
+		// existing blob is not uploaded again
+		if err := r.PushBlob(context.Background(), blob); err != nil || reg.uploads != 1 {
+			t.Errorf("got: %v uploads: %v", err, reg.uploads)
+		}
+		config := NewOCIBlob(MediaTypeOCIConfig, []byte("{}"))
+		if err := r.PushBlob(context.Background(), config); err != nil {
+			t.Fatal(err)
+		}
+		m, err := NewOCIJSONBlob(MediaTypeOCIManifest, OCIManifest{SchemaVersion: 2, MediaType: MediaTypeOCIManifest, Config: config.OCIDescriptor, Layers: []OCIDescriptor{blob.OCIDescriptor}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = r.PushManifest(context.Background(), "v1", m); err != nil {
+			t.Fatal(err)
+		}
+		if string(reg.manifests["v1"]) != string(m.Data) {
+			t.Errorf("got: %s", reg.manifests["v1"])
+		}
+
+		// wrong password
+		r = &Registry{Host: host, Repository: "team/app", Username: "user", Password: "wrong", Insecure: true}
+		if err = r.PushBlob(context.Background(), blob); err == nil {
+			t.Error("expected error")
+		}
+		srv.Close()
+	}
+}
+
+func TestParseRegistryReference(t *testing.T) {
+	for ref, want := range map[string][2]string{
+		"alpine":                        {"registry-1.docker.io", "library/alpine"},
+		"bukowa/gorelease":              {"registry-1.docker.io", "bukowa/gorelease"},
+		"docker.io/bukowa/gorelease":    {"registry-1.docker.io", "bukowa/gorelease"},
+		"localhost:5000/app":            {"localhost:5000", "app"},
+		"quay.io/bukow/gorelease":       {"quay.io", "bukow/gorelease"},
+		"ghcr.io/bukowa/gorelease/bins": {"ghcr.io", "bukowa/gorelease/bins"},
+	} {
+		host, repo, err := ParseRegistryReference(ref)
+		if err != nil || host != want[0] || repo != want[1] {
+			t.Errorf("%s got: %v %v %v want: %v", ref, host, repo, err, want)
+		}
+	}
+	if _, _, err := ParseRegistryReference("quay.io/app:v1"); err == nil {
+		t.Error("expected error")
+	}
+}
@@ -0,0 +1,378 @@
+package gorelease
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"github.com/pkg/errors"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterPublisher(AzurePublisherName, func() Publisher { return &AzurePublisher{} })
+}
+
+// AzurePublisherName is name of Azure Blob Storage publisher
+const AzurePublisherName = "azure"
+
+// default env variables of Azure credentials
+const (
+	DefaultAzureConnectionStringEnv = "AZURE_STORAGE_CONNECTION_STRING"
+	DefaultAzureSASTokenEnv         = "AZURE_STORAGE_SAS_TOKEN"
+)
+
+// AzureAPIVersion is version of blob service REST API
+const AzureAPIVersion = "2019-12-12"
+
+// DefaultAzureBlockSize is size of blocks of uploaded files,
+// smaller files are uploaded with single request
+const DefaultAzureBlockSize = 64 << 20
+
+// AzureDevelopmentStorage is connection string of Azurite emulator
+const AzureDevelopmentStorage = "DefaultEndpointsProtocol=http;AccountName=devstoreaccount1;" +
+	"AccountKey=Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw==;" +
+	"BlobEndpoint=http://127.0.0.1:10000/devstoreaccount1;"
+
+// AzurePublisher is Publisher uploading artifacts as block blobs
+// to Azure Blob Storage container, blobs are named like local paths of artifacts
+type AzurePublisher struct {
+	Container           string `yaml:"container"`
+	Prefix              string `yaml:"prefix"`                // prefix of blob names
+	Account             string `yaml:"account"`               // storage account if connection string is not set
+	Endpoint            string `yaml:"endpoint"`              // blob endpoint, https://account.blob.core.windows.net if empty
+	ConnectionStringEnv string `yaml:"connection_string_env"` // env with connection string
+	SASTokenEnv         string `yaml:"sas_token_env"`         // env with SAS token of container
+	BlockSize           int64  `yaml:"block_size"`            // DefaultAzureBlockSize if zero
+
+	Client *http.Client     `yaml:"-"` // http.DefaultClient if nil
+	Now    func() time.Time `yaml:"-"` // time of requests, time.Now if nil
+}
+
+var (
+	ErrorContainerNotSet        = errors.New("container is not set")
+	ErrorAzureCredentialsNotSet = errors.New("azure connection string or sas token is not set")
+	ErrorAzureConnectionString  = errors.New("malformed azure connection string")
+)
+
+// AzureError is returned when blob service responds with error
+type AzureError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *AzureError) Error() string {
+	return fmt.Sprintf("azure responded with %d: %s", e.StatusCode, e.Body)
+}
+
+// AzureCredentials are endpoint and shared key or SAS token of storage account
+type AzureCredentials struct {
+	Account  string
+	Key      []byte // decoded account key, shared key auth is used if set
+	SASToken string // query string of SAS token
+	Endpoint string // blob endpoint without trailing slash
+}
+
+// ParseAzureConnectionString parses storage account connection string
+func ParseAzureConnectionString(s string) (AzureCredentials, error) {
+	var c AzureCredentials
+	values := map[string]string{}
+	for _, part := range strings.Split(s, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		i := strings.Index(part, "=")
+		if i < 0 {
+			return c, ErrorAzureConnectionString
+		}
+		values[part[:i]] = part[i+1:]
+	}
+	if values["UseDevelopmentStorage"] == "true" {
+		return ParseAzureConnectionString(AzureDevelopmentStorage)
+	}
+
+	c.Account = values["AccountName"]
+	c.SASToken = strings.TrimPrefix(values["SharedAccessSignature"], "?")
+	if key := values["AccountKey"]; key != "" {
+		b, err := base64.StdEncoding.DecodeString(key)
+		if err != nil {
+			return c, errors.Wrap(ErrorAzureConnectionString, "AccountKey")
+		}
+		c.Key = b
+	}
+	switch {
+	case values["BlobEndpoint"] != "":
+		c.Endpoint = strings.TrimSuffix(values["BlobEndpoint"], "/")
+	case c.Account != "":
+		protocol, suffix := values["DefaultEndpointsProtocol"], values["EndpointSuffix"]
+		if protocol == "" {
+			protocol = "https"
+		}
+		if suffix == "" {
+			suffix = "core.windows.net"
+		}
+		c.Endpoint = fmt.Sprintf("%s://%s.blob.%s", protocol, c.Account, suffix)
+	default:
+		return c, ErrorAzureConnectionString
+	}
+	if c.Key != nil && c.Account == "" {
+		return c, errors.Wrap(ErrorAzureConnectionString, "AccountName")
+	}
+	if c.Key == nil && c.SASToken == "" {
+		return c, ErrorAzureCredentialsNotSet
+	}
+	return c, nil
+}
+
+func (p *AzurePublisher) Name() string {
+	return AzurePublisherName
+}
+
+func (p *AzurePublisher) Configure(unmarshal func(interface{}) error) error {
+	if err := unmarshal(p); err != nil {
+		return err
+	}
+	if p.Container == "" {
+		return ErrorContainerNotSet
+	}
+	return nil
+}
+
+func (p *AzurePublisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	creds, err := p.Credentials()
+	if err != nil {
+		return "", err
+	}
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	blob := p.BlobName(a.Path)
+	log.Printf("writing to azure blob %s", blob)
+	if info.Size() <= p.blockSize() {
+		b, err := ioutil.ReadAll(f)
+		if err != nil {
+			return "", err
+		}
+		h := http.Header{}
+		h.Set("x-ms-blob-type", "BlockBlob")
+		h.Set("x-ms-blob-content-type", contentType(a.Path))
+		err = p.put(ctx, creds, blob, nil, h, b)
+		if err != nil {
+			return "", err
+		}
+		return p.BlobURL(creds, blob), nil
+	}
+
+	// upload blocks and commit them
+	var ids []string
+	buf := make([]byte, p.blockSize())
+	for i := 0; ; i++ {
+		n, err := io.ReadFull(f, buf)
+		if err == io.EOF {
+			break
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return "", err
+		}
+		id := base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("block-%08d", i)))
+		q := url.Values{"comp": {"block"}, "blockid": {id}}
+		if err = p.put(ctx, creds, blob, q, http.Header{}, buf[:n]); err != nil {
+			return "", err
+		}
+		ids = append(ids, id)
+	}
+	var list bytes.Buffer
+	list.WriteString(`<?xml version="1.0" encoding="utf-8"?><BlockList>`)
+	for _, id := range ids {
+		list.WriteString("<Latest>" + id + "</Latest>")
+	}
+	list.WriteString("</BlockList>")
+	h := http.Header{}
+	h.Set("Content-Type", "application/xml")
+	h.Set("x-ms-blob-content-type", contentType(a.Path))
+	if err = p.put(ctx, creds, blob, url.Values{"comp": {"blocklist"}}, h, list.Bytes()); err != nil {
+		return "", err
+	}
+	return p.BlobURL(creds, blob), nil
+}
+
+func (p *AzurePublisher) Finalize(ctx context.Context, r *Release, m *Manifest) error {
+	return nil
+}
+
+// Credentials returns credentials from connection string env
+// or from account and SAS token env
+func (p *AzurePublisher) Credentials() (AzureCredentials, error) {
+	if s := os.Getenv(envOr(p.ConnectionStringEnv, DefaultAzureConnectionStringEnv)); s != "" {
+		c, err := ParseAzureConnectionString(s)
+		if err != nil {
+			return c, err
+		}
+		if p.Endpoint != "" {
+			c.Endpoint = strings.TrimSuffix(p.Endpoint, "/")
+		}
+		return c, nil
+	}
+	c := AzureCredentials{
+		Account:  p.Account,
+		SASToken: strings.TrimPrefix(os.Getenv(envOr(p.SASTokenEnv, DefaultAzureSASTokenEnv)), "?"),
+		Endpoint: strings.TrimSuffix(p.Endpoint, "/"),
+	}
+	if c.SASToken == "" {
+		return c, ErrorAzureCredentialsNotSet
+	}
+	if c.Endpoint == "" {
+		if c.Account == "" {
+			return c, ErrorAzureCredentialsNotSet
+		}
+		c.Endpoint = fmt.Sprintf("https://%s.blob.core.windows.net", c.Account)
+	}
+	return c, nil
+}
+
+// BlobName returns name of blob of local file
+func (p *AzurePublisher) BlobName(file string) string {
+	return strings.TrimPrefix(path.Join(p.Prefix, filepath.ToSlash(file)), "/")
+}
+
+// BlobURL returns url of blob without SAS token
+func (p *AzurePublisher) BlobURL(creds AzureCredentials, blob string) string {
+	u, err := url.Parse(creds.Endpoint)
+	if err != nil {
+		return creds.Endpoint + "/" + p.Container + "/" + blob
+	}
+	u.Path = path.Join("/", u.Path, p.Container, blob)
+	return u.String()
+}
+
+func (p *AzurePublisher) put(ctx context.Context, creds AzureCredentials, blob string, q url.Values, h http.Header, body []byte) error {
+	u, err := url.Parse(p.BlobURL(creds, blob))
+	if err != nil {
+		return err
+	}
+	u.RawQuery = q.Encode()
+	if creds.Key == nil && creds.SASToken != "" {
+		if u.RawQuery != "" {
+			u.RawQuery += "&"
+		}
+		u.RawQuery += creds.SASToken
+	}
+	req, err := http.NewRequest(http.MethodPut, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = int64(len(body))
+	for k, v := range h {
+		req.Header[k] = v
+	}
+	req.Header.Set("x-ms-version", AzureAPIVersion)
+	req.Header.Set("x-ms-date", p.now().UTC().Format(http.TimeFormat))
+	if creds.Key != nil {
+		AzureSignRequest(req, creds)
+	}
+
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return &AzureError{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+	}
+	return nil
+}
+
+// AzureSignRequest sets shared key Authorization header of blob service request
+func AzureSignRequest(req *http.Request, creds AzureCredentials) {
+	length := ""
+	if req.ContentLength > 0 {
+		length = strconv.FormatInt(req.ContentLength, 10)
+	}
+	var ms []string
+	for k := range req.Header {
+		if k = strings.ToLower(k); strings.HasPrefix(k, "x-ms-") {
+			ms = append(ms, k)
+		}
+	}
+	sort.Strings(ms)
+	var headers strings.Builder
+	for _, k := range ms {
+		headers.WriteString(k + ":" + strings.TrimSpace(req.Header.Get(k)) + "\n")
+	}
+
+	resource := "/" + creds.Account + req.URL.EscapedPath()
+	q := req.URL.Query()
+	var keys []string
+	for k := range q {
+		keys = append(keys, strings.ToLower(k))
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		vs := append([]string(nil), q[k]...)
+		sort.Strings(vs)
+		resource += "\n" + k + ":" + strings.Join(vs, ",")
+	}
+
+	toSign := strings.Join([]string{
+		req.Method,
+		req.Header.Get("Content-Encoding"),
+		req.Header.Get("Content-Language"),
+		length,
+		req.Header.Get("Content-MD5"),
+		req.Header.Get("Content-Type"),
+		"", // date, x-ms-date is used
+		req.Header.Get("If-Modified-Since"),
+		req.Header.Get("If-Match"),
+		req.Header.Get("If-None-Match"),
+		req.Header.Get("If-Unmodified-Since"),
+		req.Header.Get("Range"),
+		headers.String() + resource,
+	}, "\n")
+	mac := hmac.New(sha256.New, creds.Key)
+	mac.Write([]byte(toSign))
+	req.Header.Set("Authorization", "SharedKey "+creds.Account+":"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+}
+
+func (p *AzurePublisher) blockSize() int64 {
+	if p.BlockSize > 0 {
+		return p.BlockSize
+	}
+	return DefaultAzureBlockSize
+}
+
+func (p *AzurePublisher) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+func (p *AzurePublisher) client() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return http.DefaultClient
+}
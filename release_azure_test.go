@@ -0,0 +1,236 @@
+package gorelease_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestParseAzureConnectionString(t *testing.T) {
+	c, err := ParseAzureConnectionString("UseDevelopmentStorage=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Account != "devstoreaccount1" || c.Endpoint != "http://127.0.0.1:10000/devstoreaccount1" || len(c.Key) != 64 {
+		t.Errorf("got: %+v", c)
+	}
+
+	c, err = ParseAzureConnectionString("DefaultEndpointsProtocol=https;AccountName=acc;AccountKey=a2V5;EndpointSuffix=core.chinacloudapi.cn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoint != "https://acc.blob.core.chinacloudapi.cn" || string(c.Key) != "key" {
+		t.Errorf("got: %+v", c)
+	}
+
+	c, err = ParseAzureConnectionString("BlobEndpoint=https://acc.blob.core.windows.net/;SharedAccessSignature=sv=2019-12-12&sig=abc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Endpoint != "https://acc.blob.core.windows.net" || c.SASToken != "sv=2019-12-12&sig=abc" || c.Key != nil {
+		t.Errorf("got: %+v", c)
+	}
+
+	for _, s := range []string{"AccountName=acc", "AccountName", "AccountKey=a2V5", "AccountName=acc;AccountKey=!"} {
+		if _, err = ParseAzureConnectionString(s); err == nil {
+			t.Errorf("expected error for %q", s)
+		}
+	}
+}
+
+// azureExampleKey is published key of storage emulator account
+const azureExampleKey = "Eby8vdM02xNOcqFlqUwJPLlmEtlCDXJ1OUzFT50uSRZ6IFsuFq2UVErCz4I6tq/K1SZFPTOtr/KBHBeksoGMGw=="
+
+// TestAzureSignRequest checks signatures of fixed strings to sign, written in format
+// of shared key documentation and signed independently of AzureSignRequest with
+// printf "$toSign" | openssl dgst -sha256 -mac HMAC -macopt hexkey:$(echo $key | base64 -d | xxd -p -c 64) -binary | base64
+func TestAzureSignRequest(t *testing.T) {
+	key, _ := base64.StdEncoding.DecodeString(azureExampleKey)
+	creds := AzureCredentials{Account: "myaccount", Key: key}
+	for _, c := range []struct {
+		method, url, body, contentType string
+		headers                        map[string]string
+		signature                      string
+	}{
+		{
+			// GET\n\n\n\n\n\n\n\n\n\n\n\nx-ms-date:Fri, 26 Jun 2015 23:39:12 GMT\nx-ms-version:2015-02-21\n
+			// /myaccount/mycontainer\ncomp:metadata\nrestype:container\ntimeout:20
+			method: http.MethodGet,
+			url:    "https://myaccount.blob.core.windows.net/mycontainer?restype=container&comp=metadata&timeout=20",
+			headers: map[string]string{
+				"x-ms-date":    "Fri, 26 Jun 2015 23:39:12 GMT",
+				"x-ms-version": "2015-02-21",
+			},
+			signature: "1u9lui2jDxj0+fpbHjQ5m5NnastJRSYM+PSmfi8TXx4=",
+		},
+		{
+			// PUT\n\n\n11\n\ntext/plain\n\n\n\n\n\n\nx-ms-blob-type:BlockBlob\nx-ms-date:Fri, 26 Jun 2015 23:39:12 GMT\n
+			// x-ms-version:2015-02-21\n/myaccount/mycontainer/my%20blob.txt\nblockid:AAAA\ncomp:block
+			method:      http.MethodPut,
+			url:         "https://myaccount.blob.core.windows.net/mycontainer/my%20blob.txt?comp=block&blockid=AAAA",
+			body:        "hello world",
+			contentType: "text/plain",
+			headers: map[string]string{
+				"X-Ms-Blob-Type": "BlockBlob",
+				"x-ms-date":      "Fri, 26 Jun 2015 23:39:12 GMT",
+				"x-ms-version":   "2015-02-21",
+			},
+			signature: "LN/+xMXWZ1ZNk9Jy+vkQcop+FjW+lDx2998ImQOpa2M=",
+		},
+	} {
+		req, _ := http.NewRequest(c.method, c.url, strings.NewReader(c.body))
+		if c.contentType != "" {
+			req.Header.Set("Content-Type", c.contentType)
+		}
+		for k, v := range c.headers {
+			req.Header.Set(k, v)
+		}
+		AzureSignRequest(req, creds)
+		if got, want := req.Header.Get("Authorization"), "SharedKey myaccount:"+c.signature; got != want {
+			t.Errorf("%s %s got: %v want: %v", c.method, c.url, got, want)
+		}
+	}
+}
+
+// fakeAzure is in-process blob service verifying shared key
+// signatures or SAS token of requests
+type fakeAzure struct {
+	mu     sync.Mutex
+	creds  AzureCredentials
+	blobs  map[string][]byte
+	types  map[string]string
+	blocks map[string][]byte
+}
+
+func newFakeAzure(t *testing.T, creds AzureCredentials) (*fakeAzure, *httptest.Server) {
+	s := &fakeAzure{creds: creds, blobs: map[string][]byte{}, types: map[string]string{}, blocks: map[string][]byte{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		if s.creds.Key != nil {
+			auth := r.Header.Get("Authorization")
+			check, _ := http.NewRequest(r.Method, r.URL.String(), nil)
+			check.Header = r.Header.Clone()
+			check.ContentLength = r.ContentLength
+			AzureSignRequest(check, s.creds)
+			if auth == "" || check.Header.Get("Authorization") != auth {
+				http.Error(w, "AuthenticationFailed", http.StatusForbidden)
+				return
+			}
+		} else if r.URL.Query().Get("sig") != "secret" {
+			http.Error(w, "AuthenticationFailed", http.StatusForbidden)
+			return
+		}
+		if r.Header.Get("x-ms-version") == "" {
+			http.Error(w, "MissingRequiredHeader", http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		q := r.URL.Query()
+		switch q.Get("comp") {
+		case "block":
+			s.blocks[q.Get("blockid")] = b
+		case "blocklist":
+			var blob []byte
+			for _, part := range strings.Split(string(b), "<Latest>")[1:] {
+				blob = append(blob, s.blocks[strings.Split(part, "</Latest>")[0]]...)
+			}
+			s.blobs[r.URL.Path] = blob
+			s.types[r.URL.Path] = r.Header.Get("x-ms-blob-content-type")
+		default:
+			if r.Header.Get("x-ms-blob-type") != "BlockBlob" {
+				http.Error(w, "InvalidBlobType", http.StatusBadRequest)
+				return
+			}
+			s.blobs[r.URL.Path] = b
+			s.types[r.URL.Path] = r.Header.Get("x-ms-blob-content-type")
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	return s, srv
+}
+
+func TestAzurePublisher(t *testing.T) {
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	bin := r.Targets[0].FileBuilds[0].BinPath
+
+	creds, err := ParseAzureConnectionString("UseDevelopmentStorage=true")
+	if err != nil {
+		t.Fatal(err)
+	}
+	azure, srv := newFakeAzure(t, creds)
+	defer srv.Close()
+
+	// shared key from connection string, blocks of 2 bytes
+	os.Setenv("TEST_AZURE_CONNECTION_STRING", "UseDevelopmentStorage=true")
+	defer os.Unsetenv("TEST_AZURE_CONNECTION_STRING")
+	p := &AzurePublisher{
+		Container:           "releases",
+		Endpoint:            srv.URL + "/devstoreaccount1",
+		ConnectionStringEnv: "TEST_AZURE_CONNECTION_STRING",
+		BlockSize:           2,
+	}
+	result := make(Result)
+	if _, err = Publish(context.Background(), r, p, result); err != nil {
+		t.Fatal(err)
+	}
+	blob := "/devstoreaccount1/releases/" + p.BlobName(bin)
+	if string(azure.blobs[blob]) != "linux" || azure.types[blob] != "application/octet-stream" {
+		t.Errorf("got: %q %q", azure.blobs[blob], azure.types[blob])
+	}
+	if result[bin] != srv.URL+blob {
+		t.Errorf("got: %v want: %v", result[bin], srv.URL+blob)
+	}
+	manifest := "/devstoreaccount1/releases/" + p.BlobName(r.ManifestPath())
+	if !bytes.Contains(azure.blobs[manifest], []byte(srv.URL+blob)) || azure.types[manifest] != "application/json" {
+		t.Errorf("got: %q %q", azure.blobs[manifest], azure.types[manifest])
+	}
+
+	// wrong key is rejected
+	os.Setenv("TEST_AZURE_CONNECTION_STRING", "AccountName=devstoreaccount1;AccountKey=a2V5")
+	if _, err = p.Publish(context.Background(), r, &r.Artifacts()[0]); err == nil {
+		t.Error("expected error")
+	} else if e, ok := err.(*AzureError); !ok || e.StatusCode != http.StatusForbidden {
+		t.Errorf("got: %v", err)
+	}
+
+	// sas token, single request
+	azure.creds = AzureCredentials{}
+	os.Unsetenv("TEST_AZURE_CONNECTION_STRING")
+	os.Setenv("TEST_AZURE_SAS", "?sv=2019-12-12&sig=secret")
+	defer os.Unsetenv("TEST_AZURE_SAS")
+	p = &AzurePublisher{
+		Container:           "sas",
+		Endpoint:            srv.URL,
+		ConnectionStringEnv: "TEST_AZURE_CONNECTION_STRING",
+		SASTokenEnv:         "TEST_AZURE_SAS",
+	}
+	url, err := p.Publish(context.Background(), r, &r.Artifacts()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != srv.URL+"/sas/"+p.BlobName(bin) || string(azure.blobs["/sas/"+p.BlobName(bin)]) != "linux" {
+		t.Errorf("got: %v", url)
+	}
+
+	os.Unsetenv("TEST_AZURE_SAS")
+	if _, err = p.Credentials(); err != ErrorAzureCredentialsNotSet {
+		t.Errorf("got: %v", err)
+	}
+}
@@ -8,90 +8,148 @@ import (
 	"github.com/pkg/errors"
 	"golang.org/x/oauth2/google"
 	"google.golang.org/api/option"
+	"io"
 	"io/ioutil"
 	"log"
-	"os"
+	"path"
 	"path/filepath"
 	"strings"
 )
 
-// local path: url
-type GCSResult map[string]string
+// GCSResult is Result of GCSRelease
+type GCSResult = Result
+
+func init() {
+	RegisterPublisher(GCSPublisherName, func() Publisher { return &GCSPublisher{} })
+}
+
+// GCSPublisherName is name of Google Cloud Storage publisher
+const GCSPublisherName = "gcs"
+
+// GCSPublisher is Google Cloud Storage Publisher,
+// objects are named like local paths of artifacts
+type GCSPublisher struct {
+	Bucket string `yaml:"bucket"`
+
+	bck *storage.BucketHandle
+}
+
+var ErrorBucketNotSet = errors.New("bucket is not set")
+
+func (p *GCSPublisher) Name() string {
+	return GCSPublisherName
+}
+
+func (p *GCSPublisher) Configure(unmarshal func(interface{}) error) error {
+	if err := unmarshal(p); err != nil {
+		return err
+	}
+	if p.Bucket == "" {
+		return ErrorBucketNotSet
+	}
+	return nil
+}
+
+func (p *GCSPublisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	if p.bck == nil {
+		bck, err := gcsBucket(ctx, p.Bucket)
+		if err != nil {
+			return "", err
+		}
+		p.bck = bck
+	}
+	return gcsUpload(ctx, p.bck, p.Bucket, a.Path)
+}
+
+func (p *GCSPublisher) Finalize(ctx context.Context, r *Release, m *Manifest) error {
+	return nil
+}
 
 // GCSRelease is Google Cloud Storage ReleaseFunc
-func GCSRelease(bucket string, result GCSResult) ReleaseFunc {
+func GCSRelease(bucket string, result GCSResult) (ReleaseFunc, error) {
 	// create default context
 	ctx := context.Background()
 
+	release, err := GCSReleaseContext(ctx, bucket, result)
+	if err != nil {
+		return nil, err
+	}
+	return func(r *Release) error {
+		return release(ctx, r)
+	}, nil
+}
+
+// GCSReleaseContext is Google Cloud Storage ReleaseContextFunc,
+// uploads are aborted when context is done
+func GCSReleaseContext(ctx context.Context, bucket string, result GCSResult) (ReleaseContextFunc, error) {
+	// get bucket
+	bck, err := gcsBucket(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	return PublishRelease(&GCSPublisher{Bucket: bucket, bck: bck}, result), nil
+}
+
+// gcsUpload writes file to object with name of its path
+func gcsUpload(ctx context.Context, bck *storage.BucketHandle, bucket, file string) (string, error) {
+	// read release file
+	b, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+
+	// create new object
+	obj := bck.Object(file)
+
+	// todo check if exists
+	// write file to object
+	w := obj.NewWriter(ctx)
+	log.Printf("writing to gcs object %s", file)
+	if _, err = w.Write(b); err != nil {
+		_ = w.Close()
+		return "", err
+	}
+
+	// close writer
+	if err = w.Close(); err != nil {
+		return "", err
+	}
+	if _, err = obj.Attrs(ctx); err != nil {
+		return "", err
+	}
+	return makeObjectURL(bucket, file), nil
+}
+
+// GCSOpener returns OpenFunc reading objects from bucket
+// named like files in dir
+func GCSOpener(ctx context.Context, bucket, dir string) (OpenFunc, error) {
+	bck, err := gcsBucket(ctx, bucket)
+	if err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context, name string) (io.ReadCloser, error) {
+		return bck.Object(path.Join(filepath.ToSlash(dir), name)).NewReader(ctx)
+	}, nil
+}
+
+func gcsBucket(ctx context.Context, bucket string) (*storage.BucketHandle, error) {
 	// get api credentials
 	creds, err := google.FindDefaultCredentials(ctx, secretmanager.DefaultAuthScopes()...)
 	if err != nil {
-		log.Fatal(errors.Wrap(err, "while finding default credentials"))
+		return nil, errors.Wrap(err, "while finding default credentials")
 	}
 
 	// create new client
 	client, err := storage.NewClient(ctx, option.WithCredentials(creds))
 	if err != nil {
-		log.Fatal(errors.Wrap(err, "while creating new client"))
-	}
-
-	// get bucket
-	bck := client.Bucket(bucket)
-
-	return func(r *Release) error {
-		return r.ForEachTargetBuild(func(target *Target, build *FileBuild) error {
-
-			// handles error
-			var handle = func(err error) {
-				log.Fatal(errors.Wrapf(err, "while releasing %s", build.BinPath))
-			}
-
-			// open release file
-			f, err := os.Open(build.BinPath)
-			if err != nil {
-				handle(err)
-			}
-
-			// read file
-			b, err := ioutil.ReadAll(f)
-			if err != nil {
-				handle(err)
-			}
-
-			// close file
-			if err = f.Close(); err != nil {
-				handle(err)
-			}
-
-			// create new object
-			obj := bck.Object(build.BinPath)
-
-			// todo check if exists
-			// write file to object
-			w := obj.NewWriter(ctx)
-			log.Printf("writing to gcs object %s", build.BinPath)
-			if _, err = w.Write(b); err != nil {
-				handle(err)
-			}
-
-			// close writer
-			err = w.Close()
-			if err != nil {
-				handle(err)
-			}
-			_, err = obj.Attrs(ctx)
-			if err != nil {
-				handle(err)
-			}
-			result[build.BinPath] = makeObjectURL(bucket, *build)
-			return nil
-		})
+		return nil, errors.Wrap(err, "while creating new client")
 	}
+	return client.Bucket(bucket), nil
 }
 
-// convertMediaLink converts object MediaLink to clean url
-func makeObjectURL(bucket string, f FileBuild) string {
-	p := filepath.ToSlash(f.BinPath)
+// makeObjectURL makes public url of object with name of local path
+func makeObjectURL(bucket string, file string) string {
+	p := filepath.ToSlash(file)
 	p = strings.TrimPrefix(p, "/")
 	return fmt.Sprintf("https://storage.googleapis.com/%s/%s", bucket, p)
 }
@@ -19,7 +19,11 @@ func TestGCSReleaser(t *testing.T) {
 		t.Error(err)
 	}
 	var res = make(GCSResult)
-	err = GCSRelease(Bucket, res)(r)
+	gcs, err := GCSRelease(Bucket, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gcs(r)
 	if err != nil {
 		t.Error(err)
 	}
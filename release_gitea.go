@@ -0,0 +1,212 @@
+package gorelease
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+func init() {
+	RegisterPublisher(GiteaPublisherName, func() Publisher { return &GiteaPublisher{} })
+}
+
+// GiteaPublisherName is name of Gitea releases publisher
+const GiteaPublisherName = "gitea"
+
+// DefaultGiteaTokenEnv is default env with Gitea access token
+const DefaultGiteaTokenEnv = "GITEA_TOKEN"
+
+// GiteaPublisher is Publisher uploading artifacts as attachments of Gitea
+// release of tag matching Version, release is created or updated
+// and attachments with same names are replaced
+type GiteaPublisher struct {
+	URL        string `yaml:"url"` // url of Gitea instance like https://gitea.example.com
+	Owner      string `yaml:"owner"`
+	Repo       string `yaml:"repo"`
+	TokenEnv   string `yaml:"token_env"` // GITEA_TOKEN if empty
+	Title      string `yaml:"title"`     // title of release, tag if empty
+	Notes      string `yaml:"notes"`     // body of release
+	NotesFile  string `yaml:"notes_file"`
+	Draft      bool   `yaml:"draft"`
+	Prerelease bool   `yaml:"prerelease"`
+
+	Client *http.Client `yaml:"-"` // http.DefaultClient if nil
+
+	release *giteaRelease
+}
+
+var (
+	ErrorURLNotSet        = errors.New("url is not set")
+	ErrorGiteaTokenNotSet = errors.New("gitea token is not set")
+)
+
+type giteaRelease struct {
+	ID      int64             `json:"id"`
+	TagName string            `json:"tag_name"`
+	HTMLURL string            `json:"html_url"`
+	Assets  []giteaAttachment `json:"assets"`
+}
+
+type giteaAttachment struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+type giteaReleaseRequest struct {
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish,omitempty"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+	Draft           bool   `json:"draft"`
+	Prerelease      bool   `json:"prerelease"`
+}
+
+func (p *GiteaPublisher) Name() string {
+	return GiteaPublisherName
+}
+
+func (p *GiteaPublisher) Configure(unmarshal func(interface{}) error) error {
+	if err := unmarshal(p); err != nil {
+		return err
+	}
+	if p.URL == "" {
+		return ErrorURLNotSet
+	}
+	if p.Owner == "" || p.Repo == "" {
+		return ErrorRepoNotSet
+	}
+	return nil
+}
+
+func (p *GiteaPublisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	c, err := p.client()
+	if err != nil {
+		return "", err
+	}
+	if p.release == nil {
+		if p.release, err = p.ensureRelease(ctx, c, r); err != nil {
+			return "", err
+		}
+	}
+
+	// replace attachment uploaded by previous run
+	name := a.FlatName(r)
+	for _, attachment := range p.release.Assets {
+		if attachment.Name == name {
+			log.Printf("deleting gitea attachment %s", name)
+			err = c.json(ctx, http.MethodDelete, p.repoURL("releases/%d/assets/%d", p.release.ID, attachment.ID), nil, nil)
+			if err != nil {
+				return "", err
+			}
+		}
+	}
+
+	log.Printf("uploading gitea attachment %s", name)
+	var attachment giteaAttachment
+	upload := p.repoURL("releases/%d/assets?", p.release.ID) + url.Values{"name": {name}}.Encode()
+	if err = c.uploadForm(ctx, upload, "attachment", a.Path, &attachment); err != nil {
+		return "", err
+	}
+	return attachment.BrowserDownloadURL, nil
+}
+
+func (p *GiteaPublisher) Finalize(ctx context.Context, r *Release, m *Manifest) error {
+	if p.release != nil {
+		log.Printf("gitea release: %s", p.release.HTMLURL)
+	}
+	return nil
+}
+
+// ensureRelease creates release of tag or updates existing one
+func (p *GiteaPublisher) ensureRelease(ctx context.Context, c *httpClient, r *Release) (*giteaRelease, error) {
+	tag, err := r.ExpandedVersion()
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		return nil, ErrorVersionNotSet
+	}
+	notes := p.Notes
+	if p.NotesFile != "" {
+		b, err := ioutil.ReadFile(p.NotesFile)
+		if err != nil {
+			return nil, err
+		}
+		notes = string(b)
+	}
+	body := giteaReleaseRequest{
+		TagName:         tag,
+		TargetCommitish: r.Meta.Commit,
+		Name:            p.Title,
+		Body:            notes,
+		Draft:           p.Draft,
+		Prerelease:      p.Prerelease,
+	}
+	if body.Name == "" {
+		body.Name = tag
+	}
+
+	existing, err := p.findRelease(ctx, c, tag)
+	if err != nil {
+		return nil, err
+	}
+	var release giteaRelease
+	if existing != nil {
+		log.Printf("updating gitea release %s", tag)
+		body.TargetCommitish = ""
+		err = c.json(ctx, http.MethodPatch, p.repoURL("releases/%d", existing.ID), body, &release)
+		return &release, err
+	}
+	log.Printf("creating gitea release %s", tag)
+	err = c.json(ctx, http.MethodPost, p.repoURL("releases"), body, &release)
+	return &release, err
+}
+
+// findRelease returns release of tag or nil if there is none, Gitea
+// older than 1.15 has no endpoint of tag, so all pages of releases are listed
+func (p *GiteaPublisher) findRelease(ctx context.Context, c *httpClient, tag string) (*giteaRelease, error) {
+	var release giteaRelease
+	err := c.json(ctx, http.MethodGet, p.repoURL("releases/tags/%s", url.PathEscape(tag)), nil, &release)
+	if err == nil {
+		return &release, nil
+	}
+	if !isNotFound(err) {
+		return nil, err
+	}
+	for next := p.repoURL("releases?limit=50"); next != ""; {
+		var releases []giteaRelease
+		if next, err = c.page(ctx, next, &releases); err != nil {
+			return nil, err
+		}
+		for i := range releases {
+			if releases[i].TagName == tag {
+				return &releases[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (p *GiteaPublisher) client() (*httpClient, error) {
+	token := os.Getenv(envOr(p.TokenEnv, DefaultGiteaTokenEnv))
+	if token == "" {
+		return nil, ErrorGiteaTokenNotSet
+	}
+	return &httpClient{client: p.Client, header: http.Header{
+		"Authorization": {"token " + token},
+		"Accept":        {"application/json"},
+	}}, nil
+}
+
+// repoURL returns url of repository API endpoint
+func (p *GiteaPublisher) repoURL(format string, args ...interface{}) string {
+	return fmt.Sprintf("%s/api/v1/repos/%s/%s/", strings.TrimSuffix(p.URL, "/"), url.PathEscape(p.Owner), url.PathEscape(p.Repo)) +
+		fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,183 @@
+package gorelease_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeGiteaRelease struct {
+	ID      int64                    `json:"id"`
+	TagName string                   `json:"tag_name"`
+	Name    string                   `json:"name"`
+	Body    string                   `json:"body"`
+	Draft   bool                     `json:"draft"`
+	Assets  []map[string]interface{} `json:"assets"`
+}
+
+// fakeGitea is in-process Gitea API with releases of owner/repo
+type fakeGitea struct {
+	mu       sync.Mutex
+	url      string
+	releases []*fakeGiteaRelease
+	files    map[string][]byte
+	nextID   int64
+	noTags   bool // like Gitea older than 1.15
+}
+
+func newFakeGitea(t *testing.T) (*fakeGitea, *httptest.Server) {
+	s := &fakeGitea{files: map[string][]byte{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Header.Get("Authorization") != "token secret" {
+			http.Error(w, `{"message":"token is required"}`, http.StatusUnauthorized)
+			return
+		}
+		const repo = "/api/v1/repos/owner/repo/releases"
+		parts := strings.Split(strings.TrimPrefix(r.URL.Path, repo), "/")
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == repo:
+			// pages of two releases linked like Gitea does
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page < 1 {
+				page = 1
+			}
+			from, to := (page-1)*2, page*2
+			if from > len(s.releases) {
+				from = len(s.releases)
+			}
+			if to < len(s.releases) {
+				w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=%d>; rel="next"`, s.url, repo, page+1))
+			} else {
+				to = len(s.releases)
+			}
+			_ = json.NewEncoder(w).Encode(s.releases[from:to])
+		case r.Method == http.MethodGet && len(parts) == 3 && parts[1] == "tags" && !s.noTags:
+			for _, rel := range s.releases {
+				if rel.TagName == parts[2] {
+					_ = json.NewEncoder(w).Encode(rel)
+					return
+				}
+			}
+			http.NotFound(w, r)
+		case r.Method == http.MethodPost && r.URL.Path == repo:
+			var rel fakeGiteaRelease
+			_ = json.NewDecoder(r.Body).Decode(&rel)
+			s.nextID++
+			rel.ID = s.nextID
+			rel.Assets = []map[string]interface{}{}
+			s.releases = append(s.releases, &rel)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(rel)
+		case r.Method == http.MethodPatch && len(parts) == 2:
+			rel := s.release(parts[1])
+			_ = json.NewDecoder(r.Body).Decode(rel)
+			_ = json.NewEncoder(w).Encode(rel)
+		case r.Method == http.MethodPost && len(parts) == 3 && parts[2] == "assets":
+			rel := s.release(parts[1])
+			f, _, err := r.FormFile("attachment")
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			b, _ := ioutil.ReadAll(f)
+			name := r.URL.Query().Get("name")
+			s.nextID++
+			attachment := map[string]interface{}{
+				"id":                   float64(s.nextID),
+				"name":                 name,
+				"browser_download_url": fmt.Sprintf("%s/attachments/%d", s.url, s.nextID),
+			}
+			rel.Assets = append(rel.Assets, attachment)
+			s.files[name] = b
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(attachment)
+		case r.Method == http.MethodDelete && len(parts) == 4 && parts[2] == "assets":
+			rel := s.release(parts[1])
+			for i, a := range rel.Assets {
+				if fmt.Sprint(a["id"]) == parts[3] {
+					rel.Assets = append(rel.Assets[:i], rel.Assets[i+1:]...)
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+			}
+			http.NotFound(w, r)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	s.url = srv.URL
+	return s, srv
+}
+
+func (s *fakeGitea) release(id string) *fakeGiteaRelease {
+	for _, rel := range s.releases {
+		if fmt.Sprint(rel.ID) == id {
+			return rel
+		}
+	}
+	return &fakeGiteaRelease{}
+}
+
+func TestGiteaPublisher(t *testing.T) {
+	os.Setenv("TEST_GITEA_TOKEN", "secret")
+	defer os.Unsetenv("TEST_GITEA_TOKEN")
+
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	gt, srv := newFakeGitea(t)
+	defer srv.Close()
+	// release of tag is beyond first page of releases
+	for _, tag := range []string{"v0.1.0", "v0.2.0", "v0.3.0"} {
+		gt.nextID++
+		gt.releases = append(gt.releases, &fakeGiteaRelease{ID: gt.nextID, TagName: tag})
+	}
+
+	p := &GiteaPublisher{URL: srv.URL, Owner: "owner", Repo: "repo", TokenEnv: "TEST_GITEA_TOKEN", Draft: true}
+	m, err := Publish(context.Background(), r, p, make(Result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gt.releases) != 4 || gt.releases[3].TagName != "v1.0.0" || !gt.releases[3].Draft {
+		t.Fatalf("got: %+v", gt.releases)
+	}
+	rel := gt.releases[3]
+	if len(rel.Assets) != 4 || string(gt.files["app"]) != "linux" || string(gt.files["app.exe"]) != "windows" {
+		t.Errorf("got: %v", rel.Assets)
+	}
+	if m.Artifacts[0].URL != rel.Assets[0]["browser_download_url"] {
+		t.Errorf("got: %v", m.Artifacts[0].URL)
+	}
+
+	// re-run updates release and replaces attachments
+	p = &GiteaPublisher{URL: srv.URL, Owner: "owner", Repo: "repo", TokenEnv: "TEST_GITEA_TOKEN", Notes: "notes"}
+	if _, err = Publish(context.Background(), r, p, make(Result)); err != nil {
+		t.Fatal(err)
+	}
+	if len(gt.releases) != 4 || len(rel.Assets) != 4 || rel.Body != "notes" || rel.Draft {
+		t.Errorf("got: %+v", rel)
+	}
+
+	// without endpoint of tag releases are listed
+	gt.noTags = true
+	p = &GiteaPublisher{URL: srv.URL, Owner: "owner", Repo: "repo", TokenEnv: "TEST_GITEA_TOKEN", Notes: "listed"}
+	if _, err = Publish(context.Background(), r, p, make(Result)); err != nil {
+		t.Fatal(err)
+	}
+	if len(gt.releases) != 4 || len(rel.Assets) != 4 || rel.Body != "listed" {
+		t.Errorf("got: %+v", rel)
+	}
+}
@@ -0,0 +1,229 @@
+package gorelease
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+func init() {
+	RegisterPublisher(GitHubPublisherName, func() Publisher { return &GitHubPublisher{} })
+}
+
+// GitHubPublisherName is name of GitHub Releases publisher
+const GitHubPublisherName = "github"
+
+// defaults of GitHub publisher
+const (
+	DefaultGitHubTokenEnv = "GITHUB_TOKEN"
+	DefaultGitHubAPIURL   = "https://api.github.com"
+)
+
+// GitHubPublisher is Publisher uploading artifacts as assets of GitHub
+// release of tag matching Version, release is created or updated
+// and assets with same names are replaced
+type GitHubPublisher struct {
+	Owner      string `yaml:"owner"`
+	Repo       string `yaml:"repo"`
+	APIURL     string `yaml:"api_url"`   // like https://github.example.com/api/v3 for GitHub Enterprise
+	TokenEnv   string `yaml:"token_env"` // GITHUB_TOKEN if empty
+	Title      string `yaml:"title"`     // title of release, tag if empty
+	Notes      string `yaml:"notes"`     // body of release
+	NotesFile  string `yaml:"notes_file"`
+	Draft      bool   `yaml:"draft"`
+	Prerelease bool   `yaml:"prerelease"`
+
+	Client *http.Client `yaml:"-"` // http.DefaultClient if nil
+
+	release *githubRelease
+}
+
+var (
+	ErrorRepoNotSet        = errors.New("owner and repo are not set")
+	ErrorGitHubTokenNotSet = errors.New("github token is not set")
+	ErrorGitHubNoUploadURL = errors.New("github release has no upload url")
+)
+
+type githubRelease struct {
+	ID         int64         `json:"id"`
+	TagName    string        `json:"tag_name"`
+	UploadURL  string        `json:"upload_url"`
+	HTMLURL    string        `json:"html_url"`
+	Draft      bool          `json:"draft"`
+	Prerelease bool          `json:"prerelease"`
+	Assets     []githubAsset `json:"assets"`
+}
+
+type githubAsset struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+type githubReleaseRequest struct {
+	TagName         string `json:"tag_name"`
+	TargetCommitish string `json:"target_commitish,omitempty"`
+	Name            string `json:"name"`
+	Body            string `json:"body"`
+	Draft           bool   `json:"draft"`
+	Prerelease      bool   `json:"prerelease"`
+}
+
+func (p *GitHubPublisher) Name() string {
+	return GitHubPublisherName
+}
+
+func (p *GitHubPublisher) Configure(unmarshal func(interface{}) error) error {
+	if err := unmarshal(p); err != nil {
+		return err
+	}
+	if p.Owner == "" || p.Repo == "" {
+		return ErrorRepoNotSet
+	}
+	return nil
+}
+
+func (p *GitHubPublisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	c, err := p.client()
+	if err != nil {
+		return "", err
+	}
+	if p.release == nil {
+		if p.release, err = p.ensureRelease(ctx, c, r); err != nil {
+			return "", err
+		}
+	}
+
+	// replace asset uploaded by previous run
+	name := a.FlatName(r)
+	for _, asset := range p.release.Assets {
+		if asset.Name == name {
+			log.Printf("deleting github asset %s", name)
+			if err = c.json(ctx, http.MethodDelete, p.repoURL("releases/assets/%d", asset.ID), nil, nil); err != nil {
+				return "", err
+			}
+		}
+	}
+
+	upload := p.release.UploadURL
+	if i := strings.Index(upload, "{"); i >= 0 {
+		upload = upload[:i]
+	}
+	if upload == "" {
+		return "", ErrorGitHubNoUploadURL
+	}
+	log.Printf("uploading github asset %s", name)
+	var asset githubAsset
+	err = c.upload(ctx, http.MethodPost, upload+"?"+url.Values{"name": {name}}.Encode(), a.Path, nil, &asset)
+	if err != nil {
+		return "", err
+	}
+	return asset.BrowserDownloadURL, nil
+}
+
+func (p *GitHubPublisher) Finalize(ctx context.Context, r *Release, m *Manifest) error {
+	if p.release != nil {
+		log.Printf("github release: %s", p.release.HTMLURL)
+	}
+	return nil
+}
+
+// ensureRelease creates release of tag or updates existing one
+func (p *GitHubPublisher) ensureRelease(ctx context.Context, c *httpClient, r *Release) (*githubRelease, error) {
+	tag, err := r.ExpandedVersion()
+	if err != nil {
+		return nil, err
+	}
+	if tag == "" {
+		return nil, ErrorVersionNotSet
+	}
+	notes, err := p.notes()
+	if err != nil {
+		return nil, err
+	}
+	body := githubReleaseRequest{
+		TagName:         tag,
+		TargetCommitish: r.Meta.Commit,
+		Name:            p.Title,
+		Body:            notes,
+		Draft:           p.Draft,
+		Prerelease:      p.Prerelease,
+	}
+	if body.Name == "" {
+		body.Name = tag
+	}
+
+	existing, err := p.findRelease(ctx, c, tag)
+	if err != nil {
+		return nil, err
+	}
+	var release githubRelease
+	if existing != nil {
+		log.Printf("updating github release %s", tag)
+		body.TargetCommitish = ""
+		err = c.json(ctx, http.MethodPatch, p.repoURL("releases/%d", existing.ID), body, &release)
+		return &release, err
+	}
+	log.Printf("creating github release %s", tag)
+	err = c.json(ctx, http.MethodPost, p.repoURL("releases"), body, &release)
+	return &release, err
+}
+
+// findRelease returns release of tag or nil if there is none, drafts
+// are not returned by tag, so all pages of releases are listed to find them
+func (p *GitHubPublisher) findRelease(ctx context.Context, c *httpClient, tag string) (*githubRelease, error) {
+	var release githubRelease
+	err := c.json(ctx, http.MethodGet, p.repoURL("releases/tags/%s", url.PathEscape(tag)), nil, &release)
+	if err == nil {
+		return &release, nil
+	}
+	if !isNotFound(err) {
+		return nil, err
+	}
+	for next := p.repoURL("releases?per_page=100"); next != ""; {
+		var releases []githubRelease
+		if next, err = c.page(ctx, next, &releases); err != nil {
+			return nil, err
+		}
+		for i := range releases {
+			if releases[i].TagName == tag {
+				return &releases[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (p *GitHubPublisher) notes() (string, error) {
+	if p.NotesFile == "" {
+		return p.Notes, nil
+	}
+	b, err := ioutil.ReadFile(p.NotesFile)
+	return string(b), err
+}
+
+func (p *GitHubPublisher) client() (*httpClient, error) {
+	token := os.Getenv(envOr(p.TokenEnv, DefaultGitHubTokenEnv))
+	if token == "" {
+		return nil, ErrorGitHubTokenNotSet
+	}
+	return &httpClient{client: p.Client, header: http.Header{
+		"Authorization": {"token " + token},
+		"Accept":        {"application/vnd.github.v3+json"},
+	}}, nil
+}
+
+// repoURL returns url of repository API endpoint
+func (p *GitHubPublisher) repoURL(format string, args ...interface{}) string {
+	api := strings.TrimSuffix(p.APIURL, "/")
+	if api == "" {
+		api = DefaultGitHubAPIURL
+	}
+	return fmt.Sprintf("%s/repos/%s/%s/", api, url.PathEscape(p.Owner), url.PathEscape(p.Repo)) + fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,209 @@
+package gorelease_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeGitHubRelease struct {
+	ID         int64                    `json:"id"`
+	TagName    string                   `json:"tag_name"`
+	Name       string                   `json:"name"`
+	Body       string                   `json:"body"`
+	Draft      bool                     `json:"draft"`
+	Prerelease bool                     `json:"prerelease"`
+	UploadURL  string                   `json:"upload_url"`
+	HTMLURL    string                   `json:"html_url"`
+	Assets     []map[string]interface{} `json:"assets"`
+}
+
+// fakeGitHub is in-process GitHub API with releases of owner/repo
+type fakeGitHub struct {
+	mu       sync.Mutex
+	url      string
+	releases []*fakeGitHubRelease
+	assets   map[string][]byte
+	nextID   int64
+}
+
+func newFakeGitHub(t *testing.T) (*fakeGitHub, *httptest.Server) {
+	s := &fakeGitHub{assets: map[string][]byte{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Header.Get("Authorization") != "token secret" {
+			http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		const repo = "/repos/owner/repo/releases"
+		path := r.URL.Path
+		switch {
+		case r.Method == http.MethodGet && path == repo:
+			// pages of two releases linked like GitHub does
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page < 1 {
+				page = 1
+			}
+			from, to := (page-1)*2, page*2
+			if from > len(s.releases) {
+				from = len(s.releases)
+			}
+			if to < len(s.releases) {
+				w.Header().Set("Link", fmt.Sprintf(`<%s%s?page=%d>; rel="next", <%s%s?page=1>; rel="first"`, s.url, repo, page+1, s.url, repo))
+			} else {
+				to = len(s.releases)
+			}
+			_ = json.NewEncoder(w).Encode(s.releases[from:to])
+		case r.Method == http.MethodGet && strings.HasPrefix(path, repo+"/tags/"):
+			for _, rel := range s.releases {
+				if rel.TagName == path[len(repo+"/tags/"):] && !rel.Draft {
+					_ = json.NewEncoder(w).Encode(rel)
+					return
+				}
+			}
+			http.NotFound(w, r)
+		case r.Method == http.MethodPost && path == repo:
+			var rel fakeGitHubRelease
+			_ = json.NewDecoder(r.Body).Decode(&rel)
+			s.nextID++
+			rel.ID = s.nextID
+			rel.UploadURL = fmt.Sprintf("%s/uploads%s/%d/assets{?name,label}", s.url, repo, rel.ID)
+			rel.HTMLURL = fmt.Sprintf("%s/owner/repo/releases/tag/%s", s.url, rel.TagName)
+			rel.Assets = []map[string]interface{}{}
+			s.releases = append(s.releases, &rel)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(rel)
+		case r.Method == http.MethodPatch && strings.HasPrefix(path, repo+"/"):
+			rel := s.release(path[len(repo)+1:])
+			_ = json.NewDecoder(r.Body).Decode(rel)
+			_ = json.NewEncoder(w).Encode(rel)
+		case r.Method == http.MethodDelete && strings.HasPrefix(path, repo+"/assets/"):
+			id, _ := strconv.ParseInt(path[len(repo+"/assets/"):], 10, 64)
+			for _, rel := range s.releases {
+				for i, a := range rel.Assets {
+					if a["id"] == float64(id) {
+						rel.Assets = append(rel.Assets[:i], rel.Assets[i+1:]...)
+						w.WriteHeader(http.StatusNoContent)
+						return
+					}
+				}
+			}
+			http.NotFound(w, r)
+		case r.Method == http.MethodPost && strings.HasPrefix(path, "/uploads"+repo+"/"):
+			rel := s.release(strings.TrimSuffix(path[len("/uploads"+repo+"/"):], "/assets"))
+			name := r.URL.Query().Get("name")
+			for _, a := range rel.Assets {
+				if a["name"] == name {
+					http.Error(w, `{"message":"Validation Failed","errors":[{"code":"already_exists"}]}`, http.StatusUnprocessableEntity)
+					return
+				}
+			}
+			b, _ := ioutil.ReadAll(r.Body)
+			s.nextID++
+			asset := map[string]interface{}{
+				"id":                   float64(s.nextID),
+				"name":                 name,
+				"browser_download_url": fmt.Sprintf("%s/owner/repo/releases/download/%s/%s", s.url, rel.TagName, name),
+			}
+			rel.Assets = append(rel.Assets, asset)
+			s.assets[name] = b
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(asset)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	s.url = srv.URL
+	return s, srv
+}
+
+func (s *fakeGitHub) release(id string) *fakeGitHubRelease {
+	for _, rel := range s.releases {
+		if strconv.FormatInt(rel.ID, 10) == id {
+			return rel
+		}
+	}
+	return &fakeGitHubRelease{}
+}
+
+func TestGitHubPublisher(t *testing.T) {
+	os.Setenv("TEST_GITHUB_TOKEN", "secret")
+	defer os.Unsetenv("TEST_GITHUB_TOKEN")
+
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	gh, srv := newFakeGitHub(t)
+	defer srv.Close()
+	// release of tag is beyond first page of releases
+	for _, tag := range []string{"v0.1.0", "v0.2.0", "v0.3.0"} {
+		gh.nextID++
+		gh.releases = append(gh.releases, &fakeGitHubRelease{ID: gh.nextID, TagName: tag})
+	}
+
+	p := &GitHubPublisher{
+		Owner:      "owner",
+		Repo:       "repo",
+		APIURL:     srv.URL,
+		TokenEnv:   "TEST_GITHUB_TOKEN",
+		Notes:      "first",
+		Draft:      true,
+		Prerelease: true,
+	}
+	m, err := Publish(context.Background(), r, p, make(Result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gh.releases) != 4 {
+		t.Fatalf("got: %v releases", len(gh.releases))
+	}
+	rel := gh.releases[3]
+	if rel.TagName != "v1.0.0" || rel.Name != "v1.0.0" || rel.Body != "first" || !rel.Draft || !rel.Prerelease {
+		t.Errorf("got: %+v", rel)
+	}
+	if len(rel.Assets) != 4 || string(gh.assets["app"]) != "linux" || string(gh.assets["app.exe"]) != "windows" {
+		t.Errorf("got: %v", rel.Assets)
+	}
+	want := srv.URL + "/owner/repo/releases/download/v1.0.0/app"
+	if m.Artifacts[0].URL != want {
+		t.Errorf("got: %v want: %v", m.Artifacts[0].URL, want)
+	}
+
+	// re-run updates draft release and replaces assets
+	p = &GitHubPublisher{Owner: "owner", Repo: "repo", APIURL: srv.URL, TokenEnv: "TEST_GITHUB_TOKEN", Notes: "second"}
+	if _, err = Publish(context.Background(), r, p, make(Result)); err != nil {
+		t.Fatal(err)
+	}
+	if len(gh.releases) != 4 || len(rel.Assets) != 4 || rel.Body != "second" || rel.Draft {
+		t.Errorf("got: %+v", rel)
+	}
+
+	// published release is found by tag
+	p = &GitHubPublisher{Owner: "owner", Repo: "repo", APIURL: srv.URL, TokenEnv: "TEST_GITHUB_TOKEN", Notes: "third"}
+	if _, err = Publish(context.Background(), r, p, make(Result)); err != nil {
+		t.Fatal(err)
+	}
+	if len(gh.releases) != 4 || len(rel.Assets) != 4 || rel.Body != "third" {
+		t.Errorf("got: %+v", rel)
+	}
+
+	// errors carry response body
+	os.Setenv("TEST_GITHUB_TOKEN", "wrong")
+	p = &GitHubPublisher{Owner: "owner", Repo: "repo", APIURL: srv.URL, TokenEnv: "TEST_GITHUB_TOKEN"}
+	_, err = p.Publish(context.Background(), r, &r.Artifacts()[0])
+	if e, ok := err.(*HTTPError); !ok || e.StatusCode != http.StatusUnauthorized || !strings.Contains(e.Body, "Bad credentials") {
+		t.Errorf("got: %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package gorelease
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+func init() {
+	RegisterPublisher(GitLabPublisherName, func() Publisher { return &GitLabPublisher{} })
+}
+
+// GitLabPublisherName is name of GitLab publisher
+const GitLabPublisherName = "gitlab"
+
+// defaults of GitLab publisher
+const (
+	DefaultGitLabURL         = "https://gitlab.com"
+	DefaultGitLabTokenEnv    = "GITLAB_TOKEN"
+	DefaultGitLabJobTokenEnv = "CI_JOB_TOKEN"
+)
+
+// GitLabPublisher is Publisher uploading artifacts to generic package
+// registry of GitLab project, release of tag matching Version
+// with links to uploaded packages is created or updated when finalized
+type GitLabPublisher struct {
+	URL       string `yaml:"url"`       // url of GitLab instance, https://gitlab.com if empty
+	Project   string `yaml:"project"`   // id or path like group/project
+	Package   string `yaml:"package"`   // name of generic package, base of project path if empty
+	TokenEnv  string `yaml:"token_env"` // GITLAB_TOKEN if empty, CI_JOB_TOKEN is used if it's not set
+	Title     string `yaml:"title"`     // title of release, tag if empty
+	Notes     string `yaml:"notes"`     // description of release
+	NotesFile string `yaml:"notes_file"`
+
+	Client *http.Client `yaml:"-"` // http.DefaultClient if nil
+
+	links []gitlabLink
+}
+
+var (
+	ErrorProjectNotSet     = errors.New("project is not set")
+	ErrorGitLabTokenNotSet = errors.New("gitlab token is not set")
+)
+
+type gitlabLink struct {
+	ID       int64  `json:"id,omitempty"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	LinkType string `json:"link_type,omitempty"`
+}
+
+type gitlabRelease struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Ref         string `json:"ref,omitempty"`
+	Assets      struct {
+		Links []gitlabLink `json:"links"`
+	} `json:"assets"`
+}
+
+func (p *GitLabPublisher) Name() string {
+	return GitLabPublisherName
+}
+
+func (p *GitLabPublisher) Configure(unmarshal func(interface{}) error) error {
+	if err := unmarshal(p); err != nil {
+		return err
+	}
+	if p.Project == "" {
+		return ErrorProjectNotSet
+	}
+	return nil
+}
+
+func (p *GitLabPublisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	c, err := p.client()
+	if err != nil {
+		return "", err
+	}
+	version, err := r.ExpandedVersion()
+	if err != nil {
+		return "", err
+	}
+	if version == "" {
+		return "", ErrorVersionNotSet
+	}
+	name := a.FlatName(r)
+	u := p.projectURL("packages/generic/%s/%s/%s", url.PathEscape(p.packageName()), url.PathEscape(version), url.PathEscape(name))
+	log.Printf("uploading gitlab package file %s", name)
+	if err = c.upload(ctx, http.MethodPut, u, a.Path, http.Header{"Content-Type": {"application/octet-stream"}}, nil); err != nil {
+		return "", err
+	}
+	p.links = append(p.links, gitlabLink{Name: name, URL: u, LinkType: "package"})
+	return u, nil
+}
+
+// Finalize creates or updates release with links to uploaded files
+func (p *GitLabPublisher) Finalize(ctx context.Context, r *Release, m *Manifest) error {
+	c, err := p.client()
+	if err != nil {
+		return err
+	}
+	tag, err := r.ExpandedVersion()
+	if err != nil {
+		return err
+	}
+	notes := p.Notes
+	if p.NotesFile != "" {
+		b, err := ioutil.ReadFile(p.NotesFile)
+		if err != nil {
+			return err
+		}
+		notes = string(b)
+	}
+	body := gitlabRelease{TagName: tag, Name: p.Title, Description: notes}
+	if body.Name == "" {
+		body.Name = tag
+	}
+
+	var existing gitlabRelease
+	err = c.json(ctx, http.MethodGet, p.projectURL("releases/%s", url.PathEscape(tag)), nil, &existing)
+	if e, ok := err.(*HTTPError); ok && e.StatusCode == http.StatusNotFound {
+		log.Printf("creating gitlab release %s", tag)
+		body.Ref = r.Meta.Commit
+		body.Assets.Links = p.links
+		return c.json(ctx, http.MethodPost, p.projectURL("releases"), body, nil)
+	} else if err != nil {
+		return err
+	}
+
+	log.Printf("updating gitlab release %s", tag)
+	if err = c.json(ctx, http.MethodPut, p.projectURL("releases/%s", url.PathEscape(tag)), body, nil); err != nil {
+		return err
+	}
+	// replace links added by previous run
+	for _, link := range p.links {
+		for _, old := range existing.Assets.Links {
+			if old.Name == link.Name {
+				err = c.json(ctx, http.MethodDelete, p.projectURL("releases/%s/assets/links/%d", url.PathEscape(tag), old.ID), nil, nil)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if err = c.json(ctx, http.MethodPost, p.projectURL("releases/%s/assets/links", url.PathEscape(tag)), link, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *GitLabPublisher) packageName() string {
+	if p.Package != "" {
+		return p.Package
+	}
+	return path.Base(p.Project)
+}
+
+func (p *GitLabPublisher) client() (*httpClient, error) {
+	if token := os.Getenv(envOr(p.TokenEnv, DefaultGitLabTokenEnv)); token != "" {
+		return &httpClient{client: p.Client, header: http.Header{"Private-Token": {token}}}, nil
+	}
+	if token := os.Getenv(DefaultGitLabJobTokenEnv); token != "" {
+		return &httpClient{client: p.Client, header: http.Header{"Job-Token": {token}}}, nil
+	}
+	return nil, ErrorGitLabTokenNotSet
+}
+
+// projectURL returns url of project API endpoint,
+// args must be escaped
+func (p *GitLabPublisher) projectURL(format string, args ...interface{}) string {
+	base := strings.TrimSuffix(p.URL, "/")
+	if base == "" {
+		base = DefaultGitLabURL
+	}
+	return fmt.Sprintf("%s/api/v4/projects/%s/", base, url.PathEscape(p.Project)) + fmt.Sprintf(format, args...)
+}
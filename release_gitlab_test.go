@@ -0,0 +1,148 @@
+package gorelease_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type fakeGitLabLink struct {
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	URL      string `json:"url"`
+	LinkType string `json:"link_type"`
+}
+
+type fakeGitLabRelease struct {
+	TagName     string `json:"tag_name"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Ref         string `json:"ref"`
+	Assets      struct {
+		Links []fakeGitLabLink `json:"links"`
+	} `json:"assets"`
+}
+
+// fakeGitLab is in-process GitLab API of group/app project
+type fakeGitLab struct {
+	mu       sync.Mutex
+	packages map[string][]byte
+	releases map[string]*fakeGitLabRelease
+	nextID   int64
+}
+
+func newFakeGitLab(t *testing.T) (*fakeGitLab, *httptest.Server) {
+	s := &fakeGitLab{packages: map[string][]byte{}, releases: map[string]*fakeGitLabRelease{}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Header.Get("Private-Token") != "secret" {
+			http.Error(w, `{"message":"401 Unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		const project = "/api/v4/projects/group%2Fapp/"
+		p := r.URL.EscapedPath()
+		if !strings.HasPrefix(p, project) {
+			http.NotFound(w, r)
+			return
+		}
+		p = p[len(project):]
+		parts := strings.Split(p, "/")
+		switch {
+		case r.Method == http.MethodPut && strings.HasPrefix(p, "packages/generic/"):
+			b, _ := ioutil.ReadAll(r.Body)
+			s.packages[strings.TrimPrefix(p, "packages/generic/")] = b
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPost && p == "releases":
+			var rel fakeGitLabRelease
+			_ = json.NewDecoder(r.Body).Decode(&rel)
+			for i := range rel.Assets.Links {
+				s.nextID++
+				rel.Assets.Links[i].ID = s.nextID
+			}
+			s.releases[rel.TagName] = &rel
+			w.WriteHeader(http.StatusCreated)
+		case len(parts) == 2 && parts[0] == "releases":
+			rel, ok := s.releases[parts[1]]
+			if !ok {
+				http.Error(w, `{"message":"404 Not Found"}`, http.StatusNotFound)
+				return
+			}
+			if r.Method == http.MethodPut {
+				_ = json.NewDecoder(r.Body).Decode(rel)
+			}
+			_ = json.NewEncoder(w).Encode(rel)
+		case len(parts) >= 4 && parts[2] == "assets" && parts[3] == "links":
+			rel := s.releases[parts[1]]
+			switch r.Method {
+			case http.MethodPost:
+				var link fakeGitLabLink
+				_ = json.NewDecoder(r.Body).Decode(&link)
+				s.nextID++
+				link.ID = s.nextID
+				rel.Assets.Links = append(rel.Assets.Links, link)
+			case http.MethodDelete:
+				for i, link := range rel.Assets.Links {
+					if fmt.Sprint(link.ID) == parts[4] {
+						rel.Assets.Links = append(rel.Assets.Links[:i], rel.Assets.Links[i+1:]...)
+						break
+					}
+				}
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return s, srv
+}
+
+func TestGitLabPublisher(t *testing.T) {
+	os.Setenv("TEST_GITLAB_TOKEN", "secret")
+	defer os.Unsetenv("TEST_GITLAB_TOKEN")
+
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	r.Meta.Commit = "0123456789abcdef"
+	gl, srv := newFakeGitLab(t)
+	defer srv.Close()
+
+	p := &GitLabPublisher{URL: srv.URL, Project: "group/app", TokenEnv: "TEST_GITLAB_TOKEN", Notes: "first"}
+	m, err := Publish(context.Background(), r, p, make(Result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(gl.packages["app/v1.0.0/app"]) != "linux" || string(gl.packages["app/v1.0.0/app.exe"]) != "windows" {
+		t.Errorf("got: %v packages", len(gl.packages))
+	}
+	want := srv.URL + "/api/v4/projects/group%2Fapp/packages/generic/app/v1.0.0/app"
+	if m.Artifacts[0].URL != want {
+		t.Errorf("got: %v want: %v", m.Artifacts[0].URL, want)
+	}
+	rel := gl.releases["v1.0.0"]
+	if rel == nil || rel.Ref != r.Meta.Commit || rel.Description != "first" || len(rel.Assets.Links) != 4 {
+		t.Fatalf("got: %+v", rel)
+	}
+	if rel.Assets.Links[0].URL != want || rel.Assets.Links[0].LinkType != "package" {
+		t.Errorf("got: %+v", rel.Assets.Links[0])
+	}
+
+	// re-run updates release and replaces links
+	p = &GitLabPublisher{URL: srv.URL, Project: "group/app", TokenEnv: "TEST_GITLAB_TOKEN", Notes: "second"}
+	if _, err = Publish(context.Background(), r, p, make(Result)); err != nil {
+		t.Fatal(err)
+	}
+	if rel.Description != "second" || len(rel.Assets.Links) != 4 {
+		t.Errorf("got: %+v", rel)
+	}
+}
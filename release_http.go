@@ -0,0 +1,214 @@
+package gorelease
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"hash"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	RegisterPublisher(HTTPPublisherName, func() Publisher { return &HTTPPublisher{} })
+}
+
+// HTTPPublisherName is name of generic HTTP publisher
+const HTTPPublisherName = "http"
+
+// defaults of HTTP publisher
+const (
+	DefaultHTTPPasswordEnv = "HTTP_PASSWORD"
+	DefaultHTTPTokenEnv    = "HTTP_TOKEN"
+	// DefaultHTTPPath is appended to url without templates
+	DefaultHTTPPath = "{{.Version}}/{{.Path}}"
+)
+
+// HTTPPublisher is Publisher uploading every artifact with PUT request
+// to templated url, like Artifactory, Nexus raw repository or WebDAV
+type HTTPPublisher struct {
+	URL             string            `yaml:"url"`              // template executed with HTTPTemplateData
+	Method          string            `yaml:"method"`           // PUT if empty
+	Username        string            `yaml:"username"`         // basic auth with password from PasswordEnv
+	PasswordEnv     string            `yaml:"password_env"`     // HTTP_PASSWORD if empty
+	TokenEnv        string            `yaml:"token_env"`        // bearer token, HTTP_TOKEN if empty
+	Headers         map[string]string `yaml:"headers"`          // templates executed with HTTPTemplateData
+	ChecksumHeaders bool              `yaml:"checksum_headers"` // send X-Checksum-Sha256, -Sha1 and -Md5
+	MKCOL           bool              `yaml:"mkcol"`            // create WebDAV collections of templated path
+
+	Client *http.Client `yaml:"-"` // http.DefaultClient if nil
+}
+
+// HTTPTemplateData is passed to templates of HTTPPublisher,
+// Os and Arch are empty for release wide files
+type HTTPTemplateData struct {
+	TemplateData
+	Name string       // base name of file
+	Path string       // path of file relative to release dir
+	Type ArtifactType // type of artifact
+}
+
+var ErrorHTTPAuthNotSet = errors.New("http password is not set")
+
+func (p *HTTPPublisher) Name() string {
+	return HTTPPublisherName
+}
+
+func (p *HTTPPublisher) Configure(unmarshal func(interface{}) error) error {
+	if err := unmarshal(p); err != nil {
+		return err
+	}
+	if p.URL == "" {
+		return ErrorURLNotSet
+	}
+	return nil
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	c, err := p.client()
+	if err != nil {
+		return "", err
+	}
+	data, err := MakeHTTPTemplateData(r, a)
+	if err != nil {
+		return "", err
+	}
+	u, err := ExecTemplate("url", p.urlTemplate(), data)
+	if err != nil {
+		return "", err
+	}
+
+	header := http.Header{}
+	for k, v := range p.Headers {
+		if v, err = ExecTemplate("headers."+k, v, data); err != nil {
+			return "", err
+		}
+		header.Set(k, v)
+	}
+	if p.ChecksumHeaders {
+		sums, err := fileHashes(a.Path)
+		if err != nil {
+			return "", err
+		}
+		header.Set("X-Checksum-Sha256", sums[0])
+		header.Set("X-Checksum-Sha1", sums[1])
+		header.Set("X-Checksum-Md5", sums[2])
+	}
+
+	if p.MKCOL {
+		if err = p.mkcol(ctx, c, u); err != nil {
+			return "", err
+		}
+	}
+	method := p.Method
+	if method == "" {
+		method = http.MethodPut
+	}
+	log.Printf("uploading %s %s", method, u)
+	if err = c.upload(ctx, method, u, a.Path, header, nil); err != nil {
+		return "", err
+	}
+	return u, nil
+}
+
+func (p *HTTPPublisher) Finalize(ctx context.Context, r *Release, m *Manifest) error {
+	return nil
+}
+
+// MakeHTTPTemplateData creates HTTPTemplateData of artifact
+func MakeHTTPTemplateData(r *Release, a *Artifact) (HTTPTemplateData, error) {
+	version, err := r.ExpandedVersion()
+	if err != nil {
+		return HTTPTemplateData{}, err
+	}
+	var goos, goarch string
+	if a.Build != nil {
+		goos, goarch = a.Build.GOOS, a.Build.GOARCH
+	}
+	d := HTTPTemplateData{
+		TemplateData: MakeTemplateData(r.Target, goos, goarch),
+		Name:         path.Base(filepath.ToSlash(a.Path)),
+		Path:         a.Name(r),
+		Type:         a.Type,
+	}
+	d.Version = version
+	d.Target = a.Target
+	return d, nil
+}
+
+func (p *HTTPPublisher) urlTemplate() string {
+	if strings.Contains(p.URL, "{{") {
+		return p.URL
+	}
+	return strings.TrimSuffix(p.URL, "/") + "/" + DefaultHTTPPath
+}
+
+// mkcol creates collections of templated part of url,
+// existing collections are ignored
+func (p *HTTPPublisher) mkcol(ctx context.Context, c *httpClient, u string) error {
+	tmpl := p.urlTemplate()
+	base := tmpl[:strings.LastIndex(tmpl[:strings.Index(tmpl, "{{")], "/")+1]
+	if !strings.HasPrefix(u, base) {
+		return nil
+	}
+	parts := strings.Split(strings.TrimPrefix(u, base), "/")
+	dir := strings.TrimSuffix(base, "/")
+	for _, part := range parts[:len(parts)-1] {
+		dir += "/" + part
+		req, err := http.NewRequest("MKCOL", dir, nil)
+		if err != nil {
+			return err
+		}
+		err = c.do(req.WithContext(ctx), nil)
+		if e, ok := err.(*HTTPError); ok && e.StatusCode == http.StatusMethodNotAllowed {
+			continue // collection exists
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *HTTPPublisher) client() (*httpClient, error) {
+	c := &httpClient{client: p.Client, header: http.Header{}}
+	if token := os.Getenv(envOr(p.TokenEnv, DefaultHTTPTokenEnv)); token != "" {
+		c.header.Set("Authorization", "Bearer "+token)
+	} else if p.Username != "" {
+		password := os.Getenv(envOr(p.PasswordEnv, DefaultHTTPPasswordEnv))
+		if password == "" {
+			return nil, ErrorHTTPAuthNotSet
+		}
+		req := http.Request{Header: http.Header{}}
+		req.SetBasicAuth(p.Username, password)
+		c.header.Set("Authorization", req.Header.Get("Authorization"))
+	}
+	return c, nil
+}
+
+// fileHashes returns hex encoded sha256, sha1 and md5 of file
+func fileHashes(file string) ([3]string, error) {
+	var sums [3]string
+	f, err := os.Open(file)
+	if err != nil {
+		return sums, err
+	}
+	defer f.Close()
+	hashes := []hash.Hash{sha256.New(), sha1.New(), md5.New()}
+	w := io.MultiWriter(hashes[0], hashes[1], hashes[2])
+	if _, err = io.Copy(w, f); err != nil {
+		return sums, err
+	}
+	for i, h := range hashes {
+		sums[i] = hex.EncodeToString(h.Sum(nil))
+	}
+	return sums, nil
+}
@@ -0,0 +1,125 @@
+package gorelease_test
+
+import (
+	"context"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeDAV is in-process HTTP store, with mkcol parent
+// collections must be created before files
+type fakeDAV struct {
+	mu      sync.Mutex
+	auth    string
+	mkcol   bool
+	files   map[string][]byte
+	headers map[string]http.Header
+	cols    map[string]bool
+}
+
+func newFakeDAV(t *testing.T, auth string, mkcol bool) (*fakeDAV, *httptest.Server) {
+	s := &fakeDAV{auth: auth, mkcol: mkcol, files: map[string][]byte{}, headers: map[string]http.Header{}, cols: map[string]bool{"/repo": true}}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if r.Header.Get("Authorization") != s.auth {
+			http.Error(w, `{"errors":[{"status":401,"message":"Bad credentials"}]}`, http.StatusUnauthorized)
+			return
+		}
+		parent := r.URL.Path[:strings.LastIndex(r.URL.Path, "/")]
+		if s.mkcol && !s.cols[parent] {
+			http.Error(w, "parent collection does not exist", http.StatusConflict)
+			return
+		}
+		switch r.Method {
+		case "MKCOL":
+			if s.cols[r.URL.Path] {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			s.cols[r.URL.Path] = true
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodPut:
+			b, _ := ioutil.ReadAll(r.Body)
+			s.files[r.URL.Path] = b
+			s.headers[r.URL.Path] = r.Header
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	}))
+	return s, srv
+}
+
+func TestHTTPPublisher(t *testing.T) {
+	os.Setenv("TEST_HTTP_PASSWORD", "secret")
+	defer os.Unsetenv("TEST_HTTP_PASSWORD")
+
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	dav, srv := newFakeDAV(t, "Basic dXNlcjpzZWNyZXQ=", false)
+	defer srv.Close()
+
+	p := &HTTPPublisher{
+		URL:             srv.URL + "/repo/{{.Version}}/{{if .Os}}{{.Os}}_{{.Arch}}/{{end}}{{.Name}}",
+		Username:        "user",
+		PasswordEnv:     "TEST_HTTP_PASSWORD",
+		TokenEnv:        "TEST_HTTP_TOKEN",
+		Headers:         map[string]string{"X-Target": "{{.Target}}"},
+		ChecksumHeaders: true,
+	}
+	m, err := Publish(context.Background(), r, p, make(Result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := "/repo/v1.0.0/linux_amd64/app"
+	if string(dav.files[file]) != "linux" || m.Artifacts[0].URL != srv.URL+file {
+		t.Errorf("got: %q %v", dav.files[file], m.Artifacts[0].URL)
+	}
+	h := dav.headers[file]
+	// printf linux | sha256sum
+	if h.Get("X-Checksum-Sha256") != "caf90169eefa5f807d577486b9f795ab86ae2983c5c20806cff959117e90af18" ||
+		h.Get("X-Checksum-Sha1") == "" || h.Get("X-Checksum-Md5") == "" || h.Get("X-Target") != "app" {
+		t.Errorf("got: %v", h)
+	}
+	if _, ok := dav.files["/repo/v1.0.0/"+DefaultChecksumName]; !ok || len(dav.files) != 4 {
+		t.Errorf("got: %v files", len(dav.files))
+	}
+
+	// errors carry response body
+	os.Setenv("TEST_HTTP_PASSWORD", "wrong")
+	_, err = p.Publish(context.Background(), r, &r.Artifacts()[0])
+	if e, ok := err.(*HTTPError); !ok || e.StatusCode != http.StatusUnauthorized || !strings.Contains(e.Body, "Bad credentials") {
+		t.Errorf("got: %v", err)
+	}
+}
+
+func TestHTTPPublisher_mkcol(t *testing.T) {
+	os.Setenv("TEST_HTTP_TOKEN", "token")
+	defer os.Unsetenv("TEST_HTTP_TOKEN")
+
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	dav, srv := newFakeDAV(t, "Bearer token", true)
+	defer srv.Close()
+
+	p := &HTTPPublisher{URL: srv.URL + "/repo", TokenEnv: "TEST_HTTP_TOKEN", MKCOL: true}
+	if _, err := Publish(context.Background(), r, p, make(Result)); err != nil {
+		t.Fatal(err)
+	}
+	if string(dav.files["/repo/v1.0.0/windows_amd64/app.exe"]) != "windows" || !dav.cols["/repo/v1.0.0/linux_amd64"] {
+		t.Errorf("got: %v %v", dav.files, dav.cols)
+	}
+}
@@ -0,0 +1,194 @@
+package gorelease
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func init() {
+	RegisterPublisher(LocalPublisherName, func() Publisher { return &LocalPublisher{} })
+}
+
+// LocalPublisherName is name of local directory publisher
+const LocalPublisherName = "local"
+
+// LocalPublisher is Publisher copying artifacts into directory,
+// like file share of air-gapped network, files are laid out
+// like objects of GCSRelease: Version/goos_goarch/Name
+type LocalPublisher struct {
+	Dir   string `yaml:"dir"`
+	Force bool   `yaml:"force"` // replace existing version
+
+	checked bool
+	staging string // temporary dir of forced version swapped in by Finalize
+}
+
+var (
+	ErrorDirNotSet        = errors.New("dir is not set")
+	ErrorVersionPublished = errors.New("version is already published, use force to replace it")
+)
+
+func (p *LocalPublisher) Name() string {
+	return LocalPublisherName
+}
+
+func (p *LocalPublisher) Configure(unmarshal func(interface{}) error) error {
+	if err := unmarshal(p); err != nil {
+		return err
+	}
+	if p.Dir == "" {
+		return ErrorDirNotSet
+	}
+	return nil
+}
+
+func (p *LocalPublisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	if !p.checked {
+		if err := p.prepareVersion(r); err != nil {
+			return "", err
+		}
+		p.checked = true
+	}
+	dst, err := p.Path(r, a.Path)
+	if err != nil {
+		return "", err
+	}
+	copyTo := dst
+	if p.staging != "" {
+		rel, err := filepath.Rel(r.Dir, a.Path)
+		if err != nil {
+			return "", err
+		}
+		copyTo = filepath.Join(p.staging, rel)
+	}
+	log.Printf("copying to %s", copyTo)
+	if err = copyFileTo(copyTo, a.Path); err != nil {
+		return "", err
+	}
+	return fileURL(dst), nil
+}
+
+// Finalize swaps forced version copied into staging dir with published one,
+// published version is removed only after new one is in its place
+func (p *LocalPublisher) Finalize(ctx context.Context, r *Release, m *Manifest) error {
+	if p.staging == "" {
+		return nil
+	}
+	dir := p.versionDir(r)
+	old, err := ioutil.TempDir(p.Dir, "."+filepath.Base(dir)+"-old-")
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(dir, filepath.Join(old, filepath.Base(dir))); err != nil {
+		os.Remove(old)
+		return err
+	}
+	if err = os.Rename(p.staging, dir); err != nil {
+		// put published version back
+		if rerr := os.Rename(filepath.Join(old, filepath.Base(dir)), dir); rerr == nil {
+			os.Remove(old)
+		}
+		return err
+	}
+	p.staging = ""
+	log.Printf("replaced published version %s", dir)
+	return os.RemoveAll(old)
+}
+
+// Close removes staging dir left by failed publishing
+func (p *LocalPublisher) Close() error {
+	if p.staging == "" {
+		return nil
+	}
+	err := os.RemoveAll(p.staging)
+	p.staging = ""
+	return err
+}
+
+// Path returns destination of local file
+func (p *LocalPublisher) Path(r *Release, file string) (string, error) {
+	rel, err := versionPath(r, file)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(filepath.Join(p.Dir, filepath.FromSlash(rel)))
+}
+
+// versionPath returns slash separated path of file relative to
+// parent of release dir, so version is its first element
+func versionPath(r *Release, file string) (string, error) {
+	rel, err := filepath.Rel(filepath.Dir(r.Dir), file)
+	if err != nil {
+		return "", err
+	}
+	if strings.HasPrefix(rel, "..") {
+		return "", errors.Errorf("%s is outside of release dir %s", file, r.Dir)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// versionDir returns dir of published version
+func (p *LocalPublisher) versionDir(r *Release) string {
+	return filepath.Join(p.Dir, filepath.Base(r.Dir))
+}
+
+// prepareVersion refuses to replace published version unless forced,
+// forced version is copied into staging dir next to published one
+func (p *LocalPublisher) prepareVersion(r *Release) error {
+	dir := p.versionDir(r)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !p.Force {
+		return errors.Wrap(ErrorVersionPublished, dir)
+	}
+	staging, err := ioutil.TempDir(p.Dir, "."+filepath.Base(dir)+"-")
+	if err != nil {
+		return err
+	}
+	log.Printf("published version %s is replaced after copying into %s", dir, staging)
+	p.staging = staging
+	// TempDir creates it 0700, published dirs must be readable
+	return os.Chmod(staging, 0755)
+}
+
+// copyFileTo copies src to dst through temporary file,
+// mode of src is kept
+func copyFileTo(dst, src string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(dst), ".gorelease-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err = closeAfter(tmp, copyFile(tmp, src)); err != nil {
+		return err
+	}
+	if err = os.Chmod(tmp.Name(), info.Mode().Perm()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// fileURL returns file:// url of absolute path
+func fileURL(file string) string {
+	p := filepath.ToSlash(file)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	return (&url.URL{Scheme: "file", Path: p}).String()
+}
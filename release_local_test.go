@@ -0,0 +1,85 @@
+package gorelease_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLocalPublisher(t *testing.T) {
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "gorelease-local")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	result := make(Result)
+	m, err := Publish(context.Background(), r, &LocalPublisher{Dir: dir}, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bin := filepath.Join(dir, "v1.0.0", "linux_amd64", "app")
+	b, err := ioutil.ReadFile(bin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "linux" {
+		t.Errorf("got: %q", b)
+	}
+	if info, err := os.Stat(bin); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("got: %v %v", info.Mode(), err)
+	}
+	want := "file://" + filepath.ToSlash(bin)
+	if result[r.Targets[0].FileBuilds[0].BinPath] != want || m.Artifacts[0].URL != want {
+		t.Errorf("got: %v want: %v", m.Artifacts[0].URL, want)
+	}
+	for _, name := range []string{DefaultChecksumName, ManifestName} {
+		if _, err := os.Stat(filepath.Join(dir, "v1.0.0", name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	// published version is not replaced
+	if _, err = Publish(context.Background(), r, &LocalPublisher{Dir: dir}, make(Result)); !errors.Is(err, ErrorVersionPublished) {
+		t.Errorf("got: %v", err)
+	}
+
+	// unless forced, stale files are removed
+	stale := filepath.Join(dir, "v1.0.0", "stale")
+	if err = ioutil.WriteFile(stale, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Publish(context.Background(), r, &LocalPublisher{Dir: dir, Force: true}, make(Result)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("got: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, "v1.0.0")); err != nil || info.Mode().Perm() != 0755 {
+		t.Errorf("got: %v %v", info.Mode(), err)
+	}
+
+	// failed copy keeps published version and leaves no staging dir
+	if err = os.Remove(r.Targets[0].FileBuilds[1].BinPath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Publish(context.Background(), r, &LocalPublisher{Dir: dir, Force: true}, make(Result)); err == nil {
+		t.Fatal("expected error of missing file")
+	}
+	if _, err = os.Stat(filepath.Join(dir, "v1.0.0", "windows_amd64", "app.exe")); err != nil {
+		t.Error(err)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 1 {
+		t.Errorf("got: %v files want: 1", len(files))
+	}
+}
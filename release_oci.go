@@ -0,0 +1,173 @@
+package gorelease
+
+import (
+	"context"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
+func init() {
+	RegisterPublisher(OCIPublisherName, func() Publisher { return &OCIPublisher{} })
+}
+
+// OCIPublisherName is name of OCI artifact publisher
+const OCIPublisherName = "oci"
+
+// default env variables of registry credentials
+const (
+	DefaultOCIUsernameEnv = "OCI_USERNAME"
+	DefaultOCIPasswordEnv = "OCI_PASSWORD"
+)
+
+// DefaultOCIArtifactType is media type of layers of published files
+const DefaultOCIArtifactType = "application/vnd.gorelease.file.v1"
+
+// OCIPublisher is Publisher pushing artifacts to container registry
+// like ORAS, every platform gets manifest with layer per file and
+// image index of platforms is tagged with Version, checksums and
+// manifest are in manifest without platform
+type OCIPublisher struct {
+	Repository  string `yaml:"repository"` // like registry.example.com/team/app
+	Insecure    bool   `yaml:"insecure"`   // use plain http
+	UsernameEnv string `yaml:"username_env"`
+	PasswordEnv string `yaml:"password_env"`
+	MediaType   string `yaml:"media_type"` // media type of layers, DefaultOCIArtifactType if empty
+
+	Registry *Registry `yaml:"-"` // created from Repository if nil
+
+	platforms []string // order of platforms
+	layers    map[string][]OCIDescriptor
+	builds    map[string]*FileBuild
+}
+
+var ErrorRepositoryNotSet = errors.New("repository is not set")
+
+func (p *OCIPublisher) Name() string {
+	return OCIPublisherName
+}
+
+func (p *OCIPublisher) Configure(unmarshal func(interface{}) error) error {
+	if err := unmarshal(p); err != nil {
+		return err
+	}
+	if p.Repository == "" {
+		return ErrorRepositoryNotSet
+	}
+	return nil
+}
+
+// Publish pushes file as blob and returns its url,
+// manifests are pushed by Finalize
+func (p *OCIPublisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	reg, err := p.registry()
+	if err != nil {
+		return "", err
+	}
+	b, err := ioutil.ReadFile(a.Path)
+	if err != nil {
+		return "", err
+	}
+	mediaType := p.MediaType
+	if mediaType == "" {
+		mediaType = DefaultOCIArtifactType
+	}
+	blob := NewOCIBlob(mediaType, b)
+	blob.Annotations = map[string]string{AnnotationTitle: path.Base(filepath.ToSlash(a.Path))}
+	log.Printf("pushing blob %s %s", blob.Digest, a.Path)
+	if err = reg.PushBlob(ctx, blob); err != nil {
+		return "", err
+	}
+
+	var platform string
+	if a.Build != nil {
+		platform = a.Build.GOOS + "/" + a.Build.GOARCH
+	}
+	if p.layers == nil {
+		p.layers = map[string][]OCIDescriptor{}
+		p.builds = map[string]*FileBuild{}
+	}
+	if _, ok := p.layers[platform]; !ok {
+		p.platforms = append(p.platforms, platform)
+		p.builds[platform] = a.Build
+	}
+	p.layers[platform] = append(p.layers[platform], blob.OCIDescriptor)
+	return reg.BlobURL(blob.Digest), nil
+}
+
+// Finalize pushes manifest of every platform and image index tagged with Version
+func (p *OCIPublisher) Finalize(ctx context.Context, r *Release, m *Manifest) error {
+	reg, err := p.registry()
+	if err != nil {
+		return err
+	}
+	version, err := r.ExpandedVersion()
+	if err != nil {
+		return err
+	}
+	annotations := map[string]string{
+		AnnotationVersion: version,
+		AnnotationCreated: r.Meta.Date.Format(time.RFC3339),
+	}
+	if r.Meta.Commit != "" {
+		annotations[AnnotationRevision] = r.Meta.Commit
+	}
+
+	// empty config like ORAS
+	config := NewOCIBlob(MediaTypeArtifactConfig, []byte("{}"))
+	if err = reg.PushBlob(ctx, config); err != nil {
+		return err
+	}
+
+	index := OCIIndex{SchemaVersion: 2, MediaType: MediaTypeOCIIndex, Annotations: annotations}
+	for _, platform := range p.platforms {
+		manifest, err := NewOCIJSONBlob(MediaTypeOCIManifest, OCIManifest{
+			SchemaVersion: 2,
+			MediaType:     MediaTypeOCIManifest,
+			Config:        config.OCIDescriptor,
+			Layers:        p.layers[platform],
+			Annotations:   annotations,
+		})
+		if err != nil {
+			return err
+		}
+		log.Printf("pushing manifest %s %s", manifest.Digest, platform)
+		if err = reg.PushManifest(ctx, manifest.Digest, manifest); err != nil {
+			return err
+		}
+		if b := p.builds[platform]; b != nil {
+			manifest.Platform = OCIPlatformOf(b.GOOS, b.GOARCH)
+		}
+		index.Manifests = append(index.Manifests, manifest.OCIDescriptor)
+	}
+
+	blob, err := NewOCIJSONBlob(MediaTypeOCIIndex, index)
+	if err != nil {
+		return err
+	}
+	tag := OCITag(version)
+	log.Printf("pushing index %s:%s", p.Repository, tag)
+	return reg.PushManifest(ctx, tag, blob)
+}
+
+func (p *OCIPublisher) registry() (*Registry, error) {
+	if p.Registry != nil {
+		return p.Registry, nil
+	}
+	host, repo, err := ParseRegistryReference(p.Repository)
+	if err != nil {
+		return nil, err
+	}
+	p.Registry = &Registry{
+		Host:       host,
+		Repository: repo,
+		Username:   os.Getenv(envOr(p.UsernameEnv, DefaultOCIUsernameEnv)),
+		Password:   os.Getenv(envOr(p.PasswordEnv, DefaultOCIPasswordEnv)),
+		Insecure:   p.Insecure,
+	}
+	return p.Registry, nil
+}
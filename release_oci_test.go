@@ -0,0 +1,59 @@
+package gorelease_test
+
+import (
+	"context"
+	. "github.com/bukowa/gorelease"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestOCIPublisher(t *testing.T) {
+	os.Setenv("TEST_OCI_USERNAME", "user")
+	os.Setenv("TEST_OCI_PASSWORD", "secret")
+	defer os.Unsetenv("TEST_OCI_USERNAME")
+	defer os.Unsetenv("TEST_OCI_PASSWORD")
+
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	r.Version = "v1.0.0-next+abc"
+	reg, srv := newFakeRegistry(t, false)
+	defer srv.Close()
+
+	p := &OCIPublisher{
+		Repository:  strings.TrimPrefix(srv.URL, "http://") + "/team/app",
+		Insecure:    true,
+		UsernameEnv: "TEST_OCI_USERNAME",
+		PasswordEnv: "TEST_OCI_PASSWORD",
+	}
+	m, err := Publish(context.Background(), r, p, make(Result))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(m.Artifacts[0].URL, "/v2/team/app/blobs/"+Digest([]byte("linux"))) {
+		t.Errorf("got: %v", m.Artifacts[0].URL)
+	}
+
+	index := reg.index(t, "v1.0.0-next_abc")
+	if len(index.Manifests) != 3 || index.Annotations[AnnotationVersion] != r.Version {
+		t.Fatalf("got: %+v", index)
+	}
+	linux := index.Manifests[0]
+	if linux.Platform == nil || linux.Platform.OS != "linux" || linux.Platform.Architecture != "amd64" {
+		t.Errorf("got: %+v", linux)
+	}
+	if index.Manifests[2].Platform != nil {
+		t.Errorf("got: %+v", index.Manifests[2])
+	}
+	manifest := reg.manifest(t, linux.Digest)
+	if len(manifest.Layers) != 1 || manifest.Layers[0].Annotations[AnnotationTitle] != "app" || manifest.Config.MediaType != MediaTypeArtifactConfig {
+		t.Errorf("got: %+v", manifest)
+	}
+	files := reg.manifest(t, index.Manifests[2].Digest)
+	if len(files.Layers) != 2 || files.Layers[0].Annotations[AnnotationTitle] != DefaultChecksumName || files.Layers[1].Annotations[AnnotationTitle] != ManifestName {
+		t.Errorf("got: %+v", files)
+	}
+}
@@ -0,0 +1,385 @@
+package gorelease
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/pkg/errors"
+	"io/ioutil"
+	"log"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterPublisher(S3PublisherName, func() Publisher { return &S3Publisher{} })
+}
+
+// S3PublisherName is name of S3 compatible publisher
+const S3PublisherName = "s3"
+
+// default env variables of S3 credentials
+const (
+	DefaultS3AccessKeyEnv    = "AWS_ACCESS_KEY_ID"
+	DefaultS3SecretKeyEnv    = "AWS_SECRET_ACCESS_KEY"
+	DefaultS3SessionTokenEnv = "AWS_SESSION_TOKEN"
+	DefaultS3RegionEnv       = "AWS_REGION"
+	DefaultS3Region          = "us-east-1"
+)
+
+// S3Publisher is Publisher uploading artifacts to S3 or S3 compatible
+// storage like MinIO, objects are named like local paths of artifacts
+type S3Publisher struct {
+	Bucket       string        `yaml:"bucket"`
+	Prefix       string        `yaml:"prefix"`        // prefix of object names
+	Region       string        `yaml:"region"`        // AWS_REGION or us-east-1 if empty
+	Endpoint     string        `yaml:"endpoint"`      // custom endpoint like http://localhost:9000
+	PathStyle    bool          `yaml:"path_style"`    // endpoint/bucket/key instead of bucket.endpoint/key
+	ACL          string        `yaml:"acl"`           // canned acl like public-read
+	StorageClass string        `yaml:"storage_class"` // like STANDARD_IA
+	Presign      time.Duration `yaml:"presign"`       // result has presigned urls valid for duration if set
+
+	AccessKeyEnv    string `yaml:"access_key_env"`
+	SecretKeyEnv    string `yaml:"secret_key_env"`
+	SessionTokenEnv string `yaml:"session_token_env"`
+
+	Client *http.Client     `yaml:"-"` // http.DefaultClient if nil
+	Now    func() time.Time `yaml:"-"` // time of signatures, time.Now if nil
+}
+
+var ErrorS3CredentialsNotSet = errors.New("s3 credentials are not set")
+
+// S3Error is returned when S3 responds with error
+type S3Error struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *S3Error) Error() string {
+	return fmt.Sprintf("s3 responded with %d: %s", e.StatusCode, e.Body)
+}
+
+func (p *S3Publisher) Name() string {
+	return S3PublisherName
+}
+
+func (p *S3Publisher) Configure(unmarshal func(interface{}) error) error {
+	if err := unmarshal(p); err != nil {
+		return err
+	}
+	if p.Bucket == "" {
+		return ErrorBucketNotSet
+	}
+	return nil
+}
+
+func (p *S3Publisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	b, err := ioutil.ReadFile(a.Path)
+	if err != nil {
+		return "", err
+	}
+	key := p.Key(a.Path)
+	req, err := http.NewRequest(http.MethodPut, p.ObjectURL(key), bytes.NewReader(b))
+	if err != nil {
+		return "", err
+	}
+	req = req.WithContext(ctx)
+	req.ContentLength = int64(len(b))
+	req.Header.Set("Content-Type", contentType(a.Path))
+	if p.ACL != "" {
+		req.Header.Set("x-amz-acl", p.ACL)
+	}
+	if p.StorageClass != "" {
+		req.Header.Set("x-amz-storage-class", p.StorageClass)
+	}
+	creds, err := p.credentials()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	S3SignRequest(req, creds, p.region(), hex.EncodeToString(sum[:]), p.now())
+
+	log.Printf("writing to s3 object %s", key)
+	if err = s3Do(p.client(), req); err != nil {
+		return "", err
+	}
+	if p.Presign > 0 {
+		return p.PresignURL(key, p.Presign)
+	}
+	return p.ObjectURL(key), nil
+}
+
+func (p *S3Publisher) Finalize(ctx context.Context, r *Release, m *Manifest) error {
+	return nil
+}
+
+// Key returns name of object of local file
+func (p *S3Publisher) Key(file string) string {
+	return strings.TrimPrefix(path.Join(p.Prefix, filepath.ToSlash(file)), "/")
+}
+
+// ObjectURL returns url of object with virtual hosted
+// or path style addressing
+func (p *S3Publisher) ObjectURL(key string) string {
+	u, err := url.Parse(p.endpoint())
+	if err != nil {
+		u = &url.URL{Scheme: "https", Host: p.Endpoint}
+	}
+	if p.PathStyle {
+		u.Path = path.Join("/", u.Path, p.Bucket, key)
+	} else {
+		u.Host = p.Bucket + "." + u.Host
+		u.Path = path.Join("/", u.Path, key)
+	}
+	return u.String()
+}
+
+// PresignURL returns presigned GET url of object valid for expires
+func (p *S3Publisher) PresignURL(key string, expires time.Duration) (string, error) {
+	creds, err := p.credentials()
+	if err != nil {
+		return "", err
+	}
+	u, err := url.Parse(p.ObjectURL(key))
+	if err != nil {
+		return "", err
+	}
+	return S3PresignURL(u, creds, p.region(), expires, p.now()), nil
+}
+
+func (p *S3Publisher) endpoint() string {
+	if p.Endpoint != "" {
+		return strings.TrimSuffix(p.Endpoint, "/")
+	}
+	return fmt.Sprintf("https://s3.%s.amazonaws.com", p.region())
+}
+
+func (p *S3Publisher) region() string {
+	if p.Region != "" {
+		return p.Region
+	}
+	if r := os.Getenv(DefaultS3RegionEnv); r != "" {
+		return r
+	}
+	return DefaultS3Region
+}
+
+func (p *S3Publisher) now() time.Time {
+	if p.Now != nil {
+		return p.Now()
+	}
+	return time.Now()
+}
+
+func (p *S3Publisher) client() *http.Client {
+	if p.Client != nil {
+		return p.Client
+	}
+	return http.DefaultClient
+}
+
+func (p *S3Publisher) credentials() (S3Credentials, error) {
+	creds := S3Credentials{
+		AccessKey:    os.Getenv(envOr(p.AccessKeyEnv, DefaultS3AccessKeyEnv)),
+		SecretKey:    os.Getenv(envOr(p.SecretKeyEnv, DefaultS3SecretKeyEnv)),
+		SessionToken: os.Getenv(envOr(p.SessionTokenEnv, DefaultS3SessionTokenEnv)),
+	}
+	if creds.AccessKey == "" || creds.SecretKey == "" {
+		return creds, ErrorS3CredentialsNotSet
+	}
+	return creds, nil
+}
+
+// S3Credentials are AWS access keys
+type S3Credentials struct {
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+}
+
+const s3UnsignedPayload = "UNSIGNED-PAYLOAD"
+
+// S3SignRequest signs request with AWS signature version 4,
+// payloadHash is hex encoded sha256 of body
+func S3SignRequest(req *http.Request, creds S3Credentials, region, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("x-amz-security-token", creds.SessionToken)
+	}
+
+	// sign host and every amz and content header
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		k = strings.ToLower(k)
+		if strings.HasPrefix(k, "x-amz-") || k == "content-type" || k == "content-md5" || k == "range" {
+			headers[k] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+	var names []string
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	for _, k := range names {
+		canonical.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signed := strings.Join(names, ";")
+
+	scope := s3Scope(now, region)
+	creq := strings.Join([]string{
+		req.Method,
+		s3EscapePath(req.URL.Path),
+		s3CanonicalQuery(req.URL.Query()),
+		canonical.String(),
+		signed,
+		payloadHash,
+	}, "\n")
+	sig := s3Signature(creds.SecretKey, now, region, s3StringToSign(amzDate, scope, creq))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKey, scope, signed, sig))
+}
+
+// S3PresignURL returns url of GET request signed in query string
+func S3PresignURL(u *url.URL, creds S3Credentials, region string, expires time.Duration, now time.Time) string {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	scope := s3Scope(now, region)
+	q := u.Query()
+	q.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+	q.Set("X-Amz-Credential", creds.AccessKey+"/"+scope)
+	q.Set("X-Amz-Date", amzDate)
+	q.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	q.Set("X-Amz-SignedHeaders", "host")
+	if creds.SessionToken != "" {
+		q.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+	creq := strings.Join([]string{
+		http.MethodGet,
+		s3EscapePath(u.Path),
+		s3CanonicalQuery(q),
+		"host:" + u.Host + "\n",
+		"host",
+		s3UnsignedPayload,
+	}, "\n")
+	sig := s3Signature(creds.SecretKey, now, region, s3StringToSign(amzDate, scope, creq))
+
+	signed := *u
+	signed.RawQuery = s3CanonicalQuery(q) + "&X-Amz-Signature=" + sig
+	return signed.String()
+}
+
+func s3Scope(now time.Time, region string) string {
+	return now.UTC().Format("20060102") + "/" + region + "/s3/aws4_request"
+}
+
+func s3StringToSign(amzDate, scope, canonicalRequest string) string {
+	h := sha256.Sum256([]byte(canonicalRequest))
+	return "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(h[:])
+}
+
+func s3Signature(secret string, now time.Time, region, stringToSign string) string {
+	key := []byte("AWS4" + secret)
+	for _, s := range []string{now.UTC().Format("20060102"), region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, s)
+	}
+	return hex.EncodeToString(hmacSHA256(key, stringToSign))
+}
+
+func hmacSHA256(key []byte, s string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(s))
+	return h.Sum(nil)
+}
+
+// s3Escape encodes everything but unreserved characters
+func s3Escape(s string, slash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', slash && c == '/':
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func s3EscapePath(p string) string {
+	if p == "" {
+		return "/"
+	}
+	return s3Escape(p, true)
+}
+
+func s3CanonicalQuery(q url.Values) string {
+	var keys []string
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		vs := append([]string(nil), q[k]...)
+		sort.Strings(vs)
+		for _, v := range vs {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3Do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		b, _ := ioutil.ReadAll(resp.Body)
+		return &S3Error{StatusCode: resp.StatusCode, Body: strings.TrimSpace(string(b))}
+	}
+	return nil
+}
+
+// contentType guesses content type of release file by its extension
+func contentType(file string) string {
+	name := path.Base(filepath.ToSlash(file))
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "application/gzip"
+	case strings.HasSuffix(name, ".tar.xz"):
+		return "application/x-xz"
+	case strings.HasSuffix(name, MinisignExt), strings.HasSuffix(name, ".txt"):
+		return "text/plain; charset=utf-8"
+	case strings.HasSuffix(name, GPGExt):
+		return "application/pgp-signature"
+	}
+	if t := mime.TypeByExtension(path.Ext(name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// envOr returns env name or default name if it's empty
+func envOr(name, def string) string {
+	if name == "" {
+		return def
+	}
+	return name
+}
@@ -0,0 +1,280 @@
+package gorelease
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterPublisher(SFTPPublisherName, func() Publisher { return &SFTPPublisher{} })
+}
+
+// SFTPPublisherName is name of SFTP publisher
+const SFTPPublisherName = "sftp"
+
+// default env variables of ssh key
+const (
+	DefaultSFTPKeyEnv        = "SFTP_PRIVATE_KEY"
+	DefaultSFTPPassphraseEnv = "SFTP_PASSPHRASE"
+)
+
+// SFTPPublisher is Publisher uploading artifacts over SFTP
+// into Dir with layout of LocalPublisher
+type SFTPPublisher struct {
+	Host          string `yaml:"host"`
+	Port          int    `yaml:"port"` // 22 if zero
+	User          string `yaml:"user"`
+	Key           string `yaml:"key"`            // path of private key
+	KeyEnv        string `yaml:"key_env"`        // env with private key
+	PassphraseEnv string `yaml:"passphrase_env"` // env with passphrase of private key
+	Agent         bool   `yaml:"agent"`          // authenticate with ssh agent from SSH_AUTH_SOCK
+	KnownHosts    string `yaml:"known_hosts"`    // ~/.ssh/known_hosts if empty
+	Dir           string `yaml:"dir"`            // remote base directory
+	URL           string `yaml:"url"`            // base url of Dir in result, sftp:// url if empty
+
+	conn   *ssh.Client
+	client *sftp.Client
+	agent  net.Conn // connection to ssh agent, nil without agent
+}
+
+var (
+	ErrorHostNotSet        = errors.New("host is not set")
+	ErrorSFTPAuthNotSet    = errors.New("sftp key or agent is not set")
+	ErrorSFTPAgentNotFound = errors.New("ssh agent not found, SSH_AUTH_SOCK is not set")
+	ErrorSFTPPosixRename   = errors.New("server can't replace file, posix-rename@openssh.com extension failed")
+)
+
+func (p *SFTPPublisher) Name() string {
+	return SFTPPublisherName
+}
+
+func (p *SFTPPublisher) Configure(unmarshal func(interface{}) error) error {
+	if err := unmarshal(p); err != nil {
+		return err
+	}
+	if p.Host == "" {
+		return ErrorHostNotSet
+	}
+	if p.Dir == "" {
+		return ErrorDirNotSet
+	}
+	return nil
+}
+
+func (p *SFTPPublisher) Publish(ctx context.Context, r *Release, a *Artifact) (string, error) {
+	if p.client == nil {
+		if err := p.Connect(ctx); err != nil {
+			return "", err
+		}
+	}
+	rel, err := versionPath(r, a.Path)
+	if err != nil {
+		return "", err
+	}
+	dst := path.Join(p.Dir, rel)
+	log.Printf("writing to sftp %s:%s", p.Host, dst)
+	if err = p.upload(dst, a.Path); err != nil {
+		return "", err
+	}
+	return p.fileURL(rel), nil
+}
+
+// Finalize does nothing, connection is closed by Close
+func (p *SFTPPublisher) Finalize(ctx context.Context, r *Release, m *Manifest) error {
+	return nil
+}
+
+// Connect dials host and starts sftp session,
+// everything it opened is closed if it fails
+func (p *SFTPPublisher) Connect(ctx context.Context) (err error) {
+	defer func() {
+		if err != nil {
+			_ = p.Close()
+		}
+	}()
+	config, err := p.clientConfig()
+	if err != nil {
+		return err
+	}
+	var d net.Dialer
+	c, err := d.DialContext(ctx, "tcp", p.addr())
+	if err != nil {
+		return err
+	}
+	sc, chans, reqs, err := ssh.NewClientConn(c, p.addr(), config)
+	if err != nil {
+		c.Close()
+		return err
+	}
+	p.conn = ssh.NewClient(sc, chans, reqs)
+	p.client, err = sftp.NewClient(p.conn)
+	return err
+}
+
+// Close closes sftp session, connection and connection to ssh agent,
+// Publish calls it once publishing ends, even if it fails
+func (p *SFTPPublisher) Close() error {
+	var err error
+	closeConn := func(c io.Closer) {
+		if cerr := c.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if p.client != nil {
+		closeConn(p.client)
+	}
+	if p.conn != nil {
+		closeConn(p.conn)
+	}
+	if p.agent != nil {
+		closeConn(p.agent)
+	}
+	p.client, p.conn, p.agent = nil, nil, nil
+	return err
+}
+
+// upload writes file to temporary name in dst dir and renames it to dst
+func (p *SFTPPublisher) upload(dst, src string) error {
+	b, err := ioutil.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if err = p.client.MkdirAll(path.Dir(dst)); err != nil {
+		return err
+	}
+	// random suffix keeps uploads of concurrent releases apart
+	suffix := make([]byte, 4)
+	if _, err = io.ReadFull(rand.Reader, suffix); err != nil {
+		return err
+	}
+	tmp := path.Join(path.Dir(dst), ".gorelease-"+path.Base(dst)+"-"+hex.EncodeToString(suffix))
+	f, err := p.client.Create(tmp)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(b)
+	if err = closeAfter(f, err); err != nil {
+		_ = p.client.Remove(tmp)
+		return err
+	}
+	if err = p.client.Chmod(tmp, info.Mode().Perm()); err != nil {
+		_ = p.client.Remove(tmp)
+		return err
+	}
+	// posix rename replaces dst atomically, plain rename fails if dst exists
+	// so it's used only for new files and published file is never removed
+	if err = p.client.PosixRename(tmp, dst); err != nil {
+		if _, serr := p.client.Stat(dst); serr == nil {
+			err = errors.Wrapf(ErrorSFTPPosixRename, "%s: %s", dst, err)
+		} else {
+			err = p.client.Rename(tmp, dst)
+		}
+		if err != nil {
+			_ = p.client.Remove(tmp)
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *SFTPPublisher) clientConfig() (*ssh.ClientConfig, error) {
+	var auth []ssh.AuthMethod
+	signer, err := p.signer()
+	if err != nil {
+		return nil, err
+	}
+	if signer != nil {
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if p.Agent {
+		sock := os.Getenv("SSH_AUTH_SOCK")
+		if sock == "" {
+			return nil, ErrorSFTPAgentNotFound
+		}
+		if p.agent, err = net.Dial("unix", sock); err != nil {
+			return nil, errors.Wrap(err, "while connecting to ssh agent")
+		}
+		auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(p.agent).Signers))
+	}
+	if len(auth) == 0 {
+		return nil, ErrorSFTPAuthNotSet
+	}
+
+	knownHosts := p.KnownHosts
+	if knownHosts == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	callback, err := knownhosts.New(knownHosts)
+	if err != nil {
+		return nil, errors.Wrap(err, "while reading known hosts")
+	}
+
+	user := p.User
+	if user == "" {
+		user = os.Getenv("USER")
+	}
+	return &ssh.ClientConfig{User: user, Auth: auth, HostKeyCallback: callback}, nil
+}
+
+// signer loads private key from file or env, nil if none is set
+func (p *SFTPPublisher) signer() (ssh.Signer, error) {
+	var b []byte
+	var err error
+	switch {
+	case p.Key != "":
+		if b, err = ioutil.ReadFile(p.Key); err != nil {
+			return nil, err
+		}
+	case os.Getenv(envOr(p.KeyEnv, DefaultSFTPKeyEnv)) != "":
+		b = []byte(os.Getenv(envOr(p.KeyEnv, DefaultSFTPKeyEnv)))
+	default:
+		return nil, nil
+	}
+	if pass := os.Getenv(envOr(p.PassphraseEnv, DefaultSFTPPassphraseEnv)); pass != "" {
+		return ssh.ParsePrivateKeyWithPassphrase(b, []byte(pass))
+	}
+	return ssh.ParsePrivateKey(b)
+}
+
+func (p *SFTPPublisher) addr() string {
+	port := p.Port
+	if port == 0 {
+		port = 22
+	}
+	return net.JoinHostPort(p.Host, strconv.Itoa(port))
+}
+
+func (p *SFTPPublisher) fileURL(rel string) string {
+	if p.URL != "" {
+		return strings.TrimSuffix(p.URL, "/") + "/" + rel
+	}
+	u := url.URL{Scheme: "sftp", Host: p.addr(), Path: path.Join("/", p.Dir, rel)}
+	if p.User != "" {
+		u.User = url.User(p.User)
+	}
+	return u.String()
+}
@@ -0,0 +1,218 @@
+package gorelease_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	. "github.com/bukowa/gorelease"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sshKey generates ecdsa key and returns it with its pem encoding
+func sshKey(t *testing.T) (ssh.Signer, []byte) {
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalECPrivateKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signer, err := ssh.NewSignerFromKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+// sftpServer serves sftp subsystem over ssh for client key
+func sftpServer(t *testing.T, host ssh.Signer, client ssh.PublicKey) net.Listener {
+	config := &ssh.ServerConfig{
+		PublicKeyCallback: func(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			if c.User() == "release" && bytes.Equal(key.Marshal(), client.Marshal()) {
+				return nil, nil
+			}
+			return nil, ssh.ErrNoAuth
+		},
+	}
+	config.AddHostKey(host)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				_, chans, reqs, err := ssh.NewServerConn(c, config)
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				for ch := range chans {
+					channel, requests, err := ch.Accept()
+					if err != nil {
+						return
+					}
+					go func() {
+						for req := range requests {
+							ok := req.Type == "subsystem" && string(req.Payload[4:]) == "sftp"
+							_ = req.Reply(ok, nil)
+							if ok {
+								server, err := sftp.NewServer(channel)
+								if err != nil {
+									return
+								}
+								_ = server.Serve()
+								channel.Close()
+							}
+						}
+					}()
+				}
+			}()
+		}
+	}()
+	return l
+}
+
+func TestSFTPPublisher(t *testing.T) {
+	r := checksumRelease(t, ChecksumSHA256)
+	defer os.RemoveAll(r.DestDir)
+	if err := ChecksumRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "gorelease-sftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	host, _ := sshKey(t)
+	client, key := sshKey(t)
+	l := sftpServer(t, host, client.PublicKey())
+	defer l.Close()
+	addr := l.Addr().(*net.TCPAddr)
+
+	knownHosts := filepath.Join(dir, "known_hosts")
+	line := knownhosts.Line([]string{knownhosts.Normalize(addr.String())}, host.PublicKey())
+	if err = ioutil.WriteFile(knownHosts, []byte(line+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(dir, "id_ecdsa")
+	if err = ioutil.WriteFile(keyFile, key, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	remote := filepath.ToSlash(filepath.Join(dir, "remote"))
+	p := &SFTPPublisher{
+		Host:       "127.0.0.1",
+		Port:       addr.Port,
+		User:       "release",
+		Key:        keyFile,
+		KnownHosts: knownHosts,
+		Dir:        remote,
+	}
+	result := make(Result)
+	m, err := Publish(context.Background(), r, p, result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, "remote", "v1.0.0", "linux_amd64", "app"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "linux" {
+		t.Errorf("got: %q", b)
+	}
+	want := "sftp://release@127.0.0.1:" + strconv.Itoa(addr.Port) + remote + "/v1.0.0/linux_amd64/app"
+	if m.Artifacts[0].URL != want {
+		t.Errorf("got: %v want: %v", m.Artifacts[0].URL, want)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "remote", "v1.0.0", ManifestName)); err != nil {
+		t.Error(err)
+	}
+	files, _ := ioutil.ReadDir(filepath.Join(dir, "remote", "v1.0.0", "linux_amd64"))
+	if len(files) != 1 {
+		t.Errorf("got: %v files want: 1", len(files))
+	}
+
+	// existing files are replaced
+	p.URL = "https://downloads.example.com/"
+	url, err := p.Publish(context.Background(), r, &r.Artifacts()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if url != "https://downloads.example.com/v1.0.0/linux_amd64/app" {
+		t.Errorf("got: %v", url)
+	}
+	if err = p.Close(); err != nil {
+		t.Error(err)
+	}
+
+	// unknown host key is rejected
+	other, _ := sshKey(t)
+	line = knownhosts.Line([]string{knownhosts.Normalize(addr.String())}, other.PublicKey())
+	if err = ioutil.WriteFile(knownHosts, []byte(line+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Connect(context.Background()); err == nil || !strings.Contains(err.Error(), "key mismatch") {
+		t.Errorf("got: %v", err)
+	}
+}
+
+func TestSFTPPublisher_closeAgent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gorelease-sftp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	l, err := net.Listen("unix", filepath.Join(dir, "agent.sock"))
+	if err != nil {
+		t.Skip(err)
+	}
+	defer l.Close()
+	closed := make(chan error, 1)
+	go func() {
+		c, err := l.Accept()
+		if err == nil {
+			_, err = c.Read(make([]byte, 1))
+			c.Close()
+		}
+		closed <- err
+	}()
+	os.Setenv("SSH_AUTH_SOCK", l.Addr().String())
+	defer os.Unsetenv("SSH_AUTH_SOCK")
+
+	// connection to agent is closed when known hosts can't be read
+	p := &SFTPPublisher{Host: "127.0.0.1", Agent: true, KnownHosts: filepath.Join(dir, "missing"), Dir: "/"}
+	if err = p.Connect(context.Background()); err == nil {
+		t.Fatal("expected error of missing known hosts")
+	}
+	select {
+	case err = <-closed:
+		if err != io.EOF {
+			t.Errorf("got: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Error("connection to agent was not closed")
+	}
+}
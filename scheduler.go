@@ -0,0 +1,170 @@
+package gorelease
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BuildResult is the outcome of a single FileBuild
+type BuildResult struct {
+	Target   string        // name of target
+	Build    FileBuild     // build that was executed
+	Log      []byte        // buffered log of the build
+	Duration time.Duration // how long the build took
+	Err      error         // error if build failed
+}
+
+// BuildErrors holds every failed build of a run
+type BuildErrors []BuildResult
+
+func (e BuildErrors) Error() string {
+	var msgs []string
+	for _, r := range e {
+		msgs = append(msgs, fmt.Sprintf("%s %s/%s: %s", r.Target, r.Build.GOOS, r.Build.GOARCH, r.Err))
+	}
+	return fmt.Sprintf("%d build(s) failed:\n%s", len(e), strings.Join(msgs, "\n"))
+}
+
+// Scheduler runs FileBuilds concurrently
+type Scheduler struct {
+	Jobs    int           // max number of concurrent builds
+	Results []BuildResult // results in the order builds were added
+
+	mu     sync.Mutex
+	builds []scheduledBuild
+}
+
+type scheduledBuild struct {
+	target string
+	build  FileBuild
+}
+
+// NewScheduler creates Scheduler running at most jobs builds at once,
+// if jobs is less than 1 number of CPUs is used
+func NewScheduler(jobs int) *Scheduler {
+	if jobs < 1 {
+		jobs = runtime.NumCPU()
+	}
+	return &Scheduler{Jobs: jobs}
+}
+
+// Add schedules all FileBuilds of Target
+func (s *Scheduler) Add(t *Target) {
+	for _, b := range t.FileBuilds {
+		s.builds = append(s.builds, scheduledBuild{target: t.Name, build: b})
+	}
+}
+
+// Run executes scheduled builds and waits for all of them,
+// it returns BuildErrors if any of the builds failed;
+// when ctx is done running builds are killed and pending ones are not started
+func (s *Scheduler) Run(ctx context.Context) error {
+	s.Results = make([]BuildResult, len(s.builds))
+
+	var wg sync.WaitGroup
+	var sem = make(chan struct{}, s.Jobs)
+	for i, sb := range s.builds {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			s.Results[i] = BuildResult{Target: sb.target, Build: sb.build, Err: ctx.Err()}
+			continue
+		}
+		wg.Add(1)
+		go func(i int, sb scheduledBuild) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			res := runBuild(ctx, sb)
+			s.flush(res.Log)
+			s.Results[i] = res
+		}(i, sb)
+	}
+	wg.Wait()
+
+	var errs BuildErrors
+	for _, r := range s.Results {
+		if r.Err != nil {
+			errs = append(errs, r)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Report logs duration of each build
+func (s *Scheduler) Report() {
+	var total time.Duration
+	for _, r := range s.Results {
+		status := "ok"
+		if r.Err != nil {
+			status = "failed"
+		}
+		log.Printf("%-6s %s %s/%s in %s", status, r.Target, r.Build.GOOS, r.Build.GOARCH, r.Duration.Round(time.Millisecond))
+		total += r.Duration
+	}
+	log.Printf("%d build(s) with %d job(s), total build time %s", len(s.Results), s.Jobs, total.Round(time.Millisecond))
+}
+
+// flush writes buffered build log at once so outputs don't interleave
+func (s *Scheduler) flush(b []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, _ = log.Writer().Write(b)
+}
+
+func runBuild(ctx context.Context, sb scheduledBuild) BuildResult {
+	var buf bytes.Buffer
+	logger := log.New(&buf, log.Prefix(), log.Flags())
+
+	if sb.build.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, sb.build.Timeout)
+		defer cancel()
+	}
+
+	cmd := sb.build.CommandContext(ctx)
+	logger.Print(buildCmdLog(sb.build, cmd))
+
+	// binary of previous build is removed on failure only if this build wrote it
+	before, _ := os.Stat(sb.build.BinPath)
+	start := time.Now()
+	out, code, err := runCmd(cmd)
+	duration := time.Since(start)
+
+	if len(out) > 0 {
+		logger.Print(string(out))
+	}
+	if err != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		err = cmdErr(cmd, out, code, err)
+
+		// remove partially written binary
+		after, statErr := os.Stat(sb.build.BinPath)
+		if statErr == nil && (before == nil || !os.SameFile(before, after) ||
+			!after.ModTime().Equal(before.ModTime()) || after.Size() != before.Size()) {
+			if rmErr := os.Remove(sb.build.BinPath); rmErr == nil {
+				logger.Printf("removed %s", sb.build.BinPath)
+			}
+		}
+	}
+	return BuildResult{
+		Target:   sb.target,
+		Build:    sb.build,
+		Log:      buf.Bytes(),
+		Duration: duration,
+		Err:      err,
+	}
+}
@@ -0,0 +1,110 @@
+package gorelease_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestScheduler_Run(t *testing.T) {
+	os.RemoveAll("bin")
+	r := prepareExample()
+
+	// add target that can't be built
+	broken := Target{Name: "broken", FilePath: "missing.go", Version: r.Version, DestDir: r.DestDir}
+	for _, goarch := range []string{"amd64", "386"} {
+		b, err := MakeFileBuild(broken, "linux", goarch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		broken.FileBuilds = append(broken.FileBuilds, b)
+	}
+
+	s := NewScheduler(2)
+	for i := range r.Targets {
+		s.Add(&r.Targets[i])
+	}
+	s.Add(&broken)
+
+	err := s.Run(context.Background())
+	errs, ok := err.(BuildErrors)
+	if !ok {
+		t.Fatalf("want BuildErrors got: %v", err)
+	}
+	if len(errs) != 2 {
+		t.Errorf("got: %v failed builds want: 2", len(errs))
+	}
+	if len(s.Results) != totalFiles(r)+2 {
+		t.Errorf("got: %v results want: %v", len(s.Results), totalFiles(r)+2)
+	}
+	for _, res := range s.Results {
+		if res.Target == "broken" {
+			continue
+		}
+		if res.Err != nil {
+			t.Error(res.Err)
+		}
+		if _, err := os.Stat(res.Build.BinPath); err != nil {
+			t.Error(err)
+		}
+		if res.Duration <= 0 || len(res.Log) == 0 {
+			t.Errorf("missing duration or log for %s", filepath.Base(res.Build.BinPath))
+		}
+	}
+}
+
+func TestScheduler_Run_timeout(t *testing.T) {
+	r := prepareExample()
+	target := r.Targets[0]
+	target.BuildTimeout = time.Nanosecond
+	b, err := MakeFileBuild(target, "linux", "amd64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target.FileBuilds = []FileBuild{b}
+
+	// binary of previous build is not written by failed build
+	if err = os.MkdirAll(filepath.Dir(b.BinPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(b.BinPath, []byte("previous"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(b.BinPath)
+
+	s := NewScheduler(1)
+	s.Add(&target)
+	if err := s.Run(context.Background()); err == nil {
+		t.Fatal("want error")
+	}
+	if err := s.Results[0].Err; !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("want deadline exceeded got: %v", err)
+	}
+	if got, err := ioutil.ReadFile(b.BinPath); err != nil || string(got) != "previous" {
+		t.Errorf("previous binary was removed: %q %v", got, err)
+	}
+}
+
+func TestScheduler_Run_cancel(t *testing.T) {
+	r := prepareExample()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	s := NewScheduler(1)
+	for i := range r.Targets {
+		s.Add(&r.Targets[i])
+	}
+	if err := s.Run(ctx); err == nil {
+		t.Fatal("want error")
+	}
+	for _, res := range s.Results {
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("want canceled got: %v", res.Err)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package gorelease
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/pkg/errors"
+	"path"
+	"strings"
+)
+
+// DefaultScoopMessage is a default template of commit message of scoop manifest
+const DefaultScoopMessage = "{{.Target}} {{.Version}}"
+
+// ScoopArch maps GOARCH to architecture of scoop manifest
+var ScoopArch = map[string]string{
+	"386":   "32bit",
+	"amd64": "64bit",
+	"arm64": "arm64",
+}
+
+// Scoop configures scoop bucket manifest rendered from
+// release manifest with windows builds of one target
+type Scoop struct {
+	Target        string        `yaml:"target"` // name of target, first target if empty
+	Name          string        `yaml:"name"`   // name of manifest, name of target if empty
+	Description   string        `yaml:"description"`
+	Homepage      string        `yaml:"homepage"`
+	License       string        `yaml:"license"`        // SPDX license identifier
+	Bin           []string      `yaml:"bin"`            // templates of executables, executable of target if empty
+	Notes         string        `yaml:"notes"`          // shown after install
+	Autoupdate    bool          `yaml:"autoupdate"`     // add autoupdate of urls and checksums
+	AutoupdateURL string        `yaml:"autoupdate_url"` // template of url, url of artifact with $version if empty
+	Dir           string        `yaml:"dir"`            // dir of manifest in bucket, bucket if empty
+	Bucket        GitRepository `yaml:"bucket"`         // manifest is committed into bucket if dir is set
+}
+
+// ScoopManifest is app manifest of scoop bucket
+type ScoopManifest struct {
+	Version      string                       `json:"version"`
+	Description  string                       `json:"description,omitempty"`
+	Homepage     string                       `json:"homepage,omitempty"`
+	License      string                       `json:"license,omitempty"`
+	Notes        string                       `json:"notes,omitempty"`
+	Architecture map[string]ScoopArchitecture `json:"architecture"`
+	Autoupdate   *ScoopAutoupdate             `json:"autoupdate,omitempty"`
+}
+
+// ScoopArchitecture is url of architecture
+type ScoopArchitecture struct {
+	URL  string      `json:"url"`
+	Hash interface{} `json:"hash,omitempty"`
+	Bin  []string    `json:"bin,omitempty"`
+}
+
+// ScoopAutoupdate describes urls of next versions
+type ScoopAutoupdate struct {
+	Architecture map[string]ScoopArchitecture `json:"architecture"`
+}
+
+var ErrorNoWindowsBuilds = errors.New("target has no windows artifacts")
+
+// ScoopRelease is a basic ReleaseFunc writing scoop manifest
+var ScoopRelease ReleaseFunc = func(release *Release) error {
+	return ScoopReleaseContext(context.Background(), release)
+}
+
+// ScoopReleaseContext is a basic ReleaseContextFunc writing scoop manifest from
+// manifest of published release, it's committed into bucket if it's configured
+// or written into release dir otherwise
+var ScoopReleaseContext ReleaseContextFunc = func(ctx context.Context, release *Release) error {
+	s := release.Scoop
+	if s == nil {
+		return nil
+	}
+	m, err := releaseManifest(release)
+	if err != nil {
+		return err
+	}
+	manifest, err := MakeScoopManifest(release, m)
+	if err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	name := s.Name
+	if name == "" {
+		name = targetName(release, s.Target)
+	}
+	if s.Bucket.Dir == "" {
+		return writeReleaseFile(release, name+".json", b)
+	}
+	message, err := commitMessage(release, name, s.Bucket.Message, DefaultScoopMessage)
+	if err != nil {
+		return err
+	}
+	return s.Bucket.CommitFiles(ctx, map[string][]byte{path.Join(s.Dir, name+".json"): b}, message)
+}
+
+// MakeScoopManifest creates scoop manifest with urls and sha256 of archives,
+// or executables if builds are not archived, from Manifest
+func MakeScoopManifest(r *Release, m *Manifest) (*ScoopManifest, error) {
+	s := r.Scoop
+	target, err := r.TargetNamed(s.Target)
+	if err != nil {
+		return nil, err
+	}
+	version := strings.TrimPrefix(m.Version, "v")
+	manifest := &ScoopManifest{
+		Version:      version,
+		Description:  s.Description,
+		Homepage:     s.Homepage,
+		License:      s.License,
+		Notes:        s.Notes,
+		Architecture: map[string]ScoopArchitecture{},
+	}
+	if s.Autoupdate {
+		manifest.Autoupdate = &ScoopAutoupdate{Architecture: map[string]ScoopArchitecture{}}
+	}
+	for _, goarch := range []string{"386", "amd64", "arm64"} {
+		a := m.Artifact(target.Name, "windows", goarch)
+		if a == nil {
+			continue
+		}
+		if a.URL == "" {
+			return nil, errors.Errorf("artifact %s has no url", a.Name)
+		}
+		data := MakeTemplateData(*target, "windows", goarch)
+		data.Version = m.Version
+		var bin []string
+		for _, tmpl := range s.Bin {
+			b, err := ExecTemplate("scoop.bin", tmpl, data)
+			if err != nil {
+				return nil, err
+			}
+			bin = append(bin, b)
+		}
+		exe := windowsExe(target.Name)
+		if len(bin) == 0 {
+			bin = []string{exe}
+		}
+		url := a.URL
+		if a.Type == ArtifactBinary {
+			// downloaded executable is renamed by fragment
+			url += "#/" + exe
+		}
+		arch := ScoopArch[goarch]
+		manifest.Architecture[arch] = ScoopArchitecture{URL: url, Hash: a.SHA256, Bin: bin}
+
+		if !s.Autoupdate {
+			continue
+		}
+		next := ScoopArchitecture{URL: strings.Replace(url, version, "$version", -1)}
+		if s.AutoupdateURL != "" {
+			data.Version = "$version"
+			if next.URL, err = ExecTemplate("scoop.autoupdate_url", s.AutoupdateURL, data); err != nil {
+				return nil, err
+			}
+		}
+		if m.ChecksumsURL != "" {
+			next.Hash = map[string]string{"url": strings.Replace(m.ChecksumsURL, version, "$version", -1)}
+		}
+		manifest.Autoupdate.Architecture[arch] = next
+	}
+	if len(manifest.Architecture) == 0 {
+		return nil, ErrorNoWindowsBuilds
+	}
+	return manifest, nil
+}
+
+// windowsExe returns name of windows executable of target
+func windowsExe(name string) string {
+	if path.Ext(name) == ".exe" {
+		return name
+	}
+	return name + ".exe"
+}
@@ -0,0 +1,102 @@
+package gorelease_test
+
+import (
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestScoopRelease(t *testing.T) {
+	r := manifestRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	r.Manifest.ChecksumsURL = "https://example.com/v1.2.0/checksums.txt"
+	r.Scoop = &Scoop{
+		Description: "App",
+		License:     "MIT",
+		Autoupdate:  true,
+	}
+	if err := ScoopRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadFile(filepath.Join(r.Dir, "app.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{
+    "version": "1.2.0",
+    "description": "App",
+    "license": "MIT",
+    "architecture": {
+        "32bit": {
+            "url": "https://example.com/v1.2.0/windows_386/app_v1.2.0_windows_386.zip",
+            "hash": "3333333333333333333333333333333333333333333333333333333333333333",
+            "bin": [
+                "app.exe"
+            ]
+        },
+        "64bit": {
+            "url": "https://example.com/v1.2.0/windows_amd64/app_v1.2.0_windows_amd64.zip",
+            "hash": "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+            "bin": [
+                "app.exe"
+            ]
+        },
+        "arm64": {
+            "url": "https://example.com/v1.2.0/windows_arm64/app_v1.2.0_windows_arm64.zip",
+            "hash": "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+            "bin": [
+                "app.exe"
+            ]
+        }
+    },
+    "autoupdate": {
+        "architecture": {
+            "32bit": {
+                "url": "https://example.com/v$version/windows_386/app_v$version_windows_386.zip",
+                "hash": {
+                    "url": "https://example.com/v$version/checksums.txt"
+                }
+            },
+            "64bit": {
+                "url": "https://example.com/v$version/windows_amd64/app_v$version_windows_amd64.zip",
+                "hash": {
+                    "url": "https://example.com/v$version/checksums.txt"
+                }
+            },
+            "arm64": {
+                "url": "https://example.com/v$version/windows_arm64/app_v$version_windows_arm64.zip",
+                "hash": {
+                    "url": "https://example.com/v$version/checksums.txt"
+                }
+            }
+        }
+    }
+}
+`
+	if string(b) != want {
+		t.Errorf("got:\n%s", b)
+	}
+
+	// bin templates and manifest committed into bucket
+	checkout, bare := tapRepository(t, r.DestDir)
+	r.Scoop = &Scoop{
+		Bin:    []string{"{{.Target}}_{{.Arch}}.exe"},
+		Dir:    "bucket",
+		Bucket: GitRepository{Dir: checkout, Remote: "origin", Branch: "main", Author: "Release Bot <bot@example.com>"},
+	}
+	if err := ScoopRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	manifest := git(t, bare, "show", "main:bucket/app.json")
+	if !strings.Contains(manifest, `"app_amd64.exe"`) || strings.Contains(manifest, "autoupdate") {
+		t.Errorf("got: %s", manifest)
+	}
+
+	r.Manifest.Artifacts = r.Manifest.Artifacts[:5]
+	if err := ScoopRelease(r); err != ErrorNoWindowsBuilds {
+		t.Errorf("got: %v", err)
+	}
+}
@@ -0,0 +1,200 @@
+package gorelease
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+)
+
+// MinisignExt is extension of minisign signature files
+const MinisignExt = ".minisig"
+
+// Sign configures signing of release artifacts
+type Sign struct {
+	Minisign *Minisign `yaml:"minisign"`
+	GPG      *GPG      `yaml:"gpg"`
+}
+
+// Minisign configures minisign signatures
+type Minisign struct {
+	Key         string `yaml:"key"`          // path of secret key file
+	KeyEnv      string `yaml:"key_env"`      // env with content of secret key file
+	PasswordEnv string `yaml:"password_env"` // env with password of encrypted key
+}
+
+// default env variables of minisign key
+const (
+	DefaultMinisignKeyEnv      = "MINISIGN_SECRET_KEY"
+	DefaultMinisignPasswordEnv = "MINISIGN_PASSWORD"
+)
+
+var ErrorMinisignKeyNotSet = errors.New("minisign key is not set")
+
+// PrivateKey loads secret key from file or env
+func (m *Minisign) PrivateKey() (*MinisignPrivateKey, error) {
+	keyEnv, passEnv := m.KeyEnv, m.PasswordEnv
+	if keyEnv == "" {
+		keyEnv = DefaultMinisignKeyEnv
+	}
+	if passEnv == "" {
+		passEnv = DefaultMinisignPasswordEnv
+	}
+
+	var b []byte
+	var err error
+	switch {
+	case m.Key != "":
+		if b, err = ioutil.ReadFile(m.Key); err != nil {
+			return nil, err
+		}
+	case os.Getenv(keyEnv) != "":
+		b = []byte(os.Getenv(keyEnv))
+	default:
+		return nil, ErrorMinisignKeyNotSet
+	}
+	return ParseMinisignPrivateKey(b, os.Getenv(passEnv))
+}
+
+// SignError is returned when artifact can't be signed
+// or its signature can't be verified
+type SignError struct {
+	Path string // path of signed file
+	Err  error
+}
+
+func (e *SignError) Error() string {
+	return fmt.Sprintf("signature of %s: %s", e.Path, e.Err)
+}
+
+func (e *SignError) Unwrap() error {
+	return e.Err
+}
+
+// signedArtifacts returns artifacts that get signatures
+func signedArtifacts(artifacts []Artifact) []Artifact {
+	var signed []Artifact
+	for _, a := range artifacts {
+		switch a.Type {
+		case ArtifactBinary, ArtifactArchive, ArtifactPackage, ArtifactChecksum:
+			signed = append(signed, a)
+		}
+	}
+	return signed
+}
+
+// signatureArtifacts returns signatures of signed artifacts
+func (r *Release) signatureArtifacts(signed []Artifact) []Artifact {
+	var sigs []Artifact
+	for _, a := range signed {
+		if r.Sign.Minisign != nil {
+			sigs = append(sigs, Artifact{Path: a.Path + MinisignExt, Type: ArtifactSignature, Target: a.Target, Build: a.Build})
+		}
+		if r.Sign.GPG != nil && r.Sign.GPG.Signs(a.Type) {
+			sigs = append(sigs, Artifact{Path: a.Path + GPGExt, Type: ArtifactSignature, Target: a.Target, Build: a.Build})
+		}
+	}
+	return sigs
+}
+
+// SignRelease is a basic BuildReleaseFunc signing release artifacts
+var SignRelease BuildReleaseFunc = func(release *Release) error {
+	return SignReleaseContext(context.Background(), release)
+}
+
+// SignReleaseContext is a basic BuildReleaseContextFunc writing
+// signatures of artifacts and checksums file for configured signers
+var SignReleaseContext BuildReleaseContextFunc = func(ctx context.Context, release *Release) error {
+	var err error
+	var key *MinisignPrivateKey
+	var entity *openpgp.Entity
+
+	if release.Sign.Minisign != nil {
+		if key, err = release.Sign.Minisign.PrivateKey(); err != nil {
+			return err
+		}
+	}
+	if release.Sign.GPG != nil {
+		if entity, err = release.Sign.GPG.Entity(); err != nil {
+			return err
+		}
+	}
+
+	for _, a := range signedArtifacts(release.Artifacts()) {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if key != nil {
+			log.Printf("signing: %s", a.Path+MinisignExt)
+			if err := minisignFile(key, a.Path, release.Meta); err != nil {
+				return &SignError{Path: a.Path, Err: err}
+			}
+		}
+		if entity != nil && release.Sign.GPG.Signs(a.Type) {
+			log.Printf("signing: %s", a.Path+GPGExt)
+			if err := gpgFile(entity, a.Path, release.Meta); err != nil {
+				return &SignError{Path: a.Path, Err: err}
+			}
+		}
+	}
+	return nil
+}
+
+func minisignFile(key *MinisignPrivateKey, file string, meta Meta) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	comment := fmt.Sprintf("timestamp:%d\tfile:%s", meta.Date.Unix(), path.Base(file))
+	sig, err := MinisignSign(key, f, comment)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file+MinisignExt, sig, 0644)
+}
+
+// VerifyMinisign verifies minisign signature of file name
+func VerifyMinisign(ctx context.Context, key *MinisignPublicKey, name string, open OpenFunc) error {
+	sig, err := open(ctx, name+MinisignExt)
+	if err != nil {
+		return &SignError{Path: name, Err: err}
+	}
+	b, err := ioutil.ReadAll(sig)
+	sig.Close()
+	if err != nil {
+		return &SignError{Path: name, Err: err}
+	}
+	f, err := open(ctx, name)
+	if err != nil {
+		return &SignError{Path: name, Err: err}
+	}
+	defer f.Close()
+	if _, err = MinisignVerify(key, f, b); err != nil {
+		return &SignError{Path: name, Err: err}
+	}
+	return nil
+}
+
+// VerifyMinisignRelease verifies minisign signatures of checksums file
+// name and of every file listed in it
+func VerifyMinisignRelease(ctx context.Context, key *MinisignPublicKey, name string, open OpenFunc) error {
+	if err := VerifyMinisign(ctx, key, name, open); err != nil {
+		return err
+	}
+	names, _, err := ReadChecksums(ctx, name, open)
+	if err != nil {
+		return err
+	}
+	for _, file := range names {
+		if err := VerifyMinisign(ctx, key, file, open); err != nil {
+			return err
+		}
+	}
+	log.Printf("verified minisign signatures of %d file(s) from %s", len(names)+1, name)
+	return nil
+}
@@ -0,0 +1,152 @@
+package gorelease
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Meta describes repository at the time of release
+type Meta struct {
+	Commit     string    // full hash of HEAD commit
+	CommitDate time.Time // committer date of HEAD commit
+	Date       time.Time // date of release
+}
+
+// MakeMeta gathers Meta from git repository in working directory,
+// outside of git repository only Date is set
+func MakeMeta(ctx context.Context) Meta {
+	m := Meta{Date: time.Now().UTC()}
+	out, err := runCmdErr(exec.CommandContext(ctx, "git", "log", "-1", "--format=%H %ct"))
+	if err != nil {
+		return m
+	}
+	var unix int64
+	if _, err := fmt.Sscanf(string(out), "%s %d", &m.Commit, &unix); err != nil {
+		return m
+	}
+	m.CommitDate = time.Unix(unix, 0).UTC()
+	return m
+}
+
+// TemplateData is passed to templates in Target fields
+type TemplateData struct {
+	Version     string
+	Commit      string
+	ShortCommit string
+	Date        string // RFC3339 date of release
+	CommitDate  string // RFC3339 date of commit
+	Timestamp   int64  // unix timestamp of release
+	Os          string
+	Arch        string
+	Target      string            // name of target
+	Env         map[string]string // environment variables
+}
+
+// MakeTemplateData creates TemplateData for Target built for goos and goarch
+func MakeTemplateData(t Target, goos, goarch string) TemplateData {
+	d := TemplateData{
+		Version:   t.Version,
+		Commit:    t.Meta.Commit,
+		Date:      t.Meta.Date.Format(time.RFC3339),
+		Timestamp: t.Meta.Date.Unix(),
+		Os:        goos,
+		Arch:      goarch,
+		Target:    t.Name,
+		Env:       envMap(os.Environ()),
+	}
+	if len(d.Commit) > 7 {
+		d.ShortCommit = d.Commit[:7]
+	} else {
+		d.ShortCommit = d.Commit
+	}
+	if !t.Meta.CommitDate.IsZero() {
+		d.CommitDate = t.Meta.CommitDate.Format(time.RFC3339)
+	}
+	for k, v := range t.Env {
+		d.Env[k] = v
+	}
+	return d
+}
+
+// TemplateError is returned when template can't be executed
+type TemplateError struct {
+	Field string // name of field with template
+	Text  string // template text
+	Err   error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("while executing template of %s '%s': %s", e.Field, e.Text, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// ExecTemplate executes text as template with data
+func ExecTemplate(field, text string, data interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New(field).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", &TemplateError{Field: field, Text: text, Err: err}
+	}
+	var buf bytes.Buffer
+	if err = tmpl.Execute(&buf, data); err != nil {
+		return "", &TemplateError{Field: field, Text: text, Err: err}
+	}
+	return buf.String(), nil
+}
+
+// expandTarget returns copy of Target with templates
+// in Version, Name, DestDir, Flags and Env executed
+func expandTarget(t Target, goos, goarch string) (Target, error) {
+	var err error
+	data := MakeTemplateData(t, goos, goarch)
+
+	if t.Version, err = ExecTemplate("version", t.Version, data); err != nil {
+		return t, err
+	}
+	data.Version = t.Version
+
+	if t.Name, err = ExecTemplate("name", t.Name, data); err != nil {
+		return t, err
+	}
+	if t.DestDir, err = ExecTemplate("dir", t.DestDir, data); err != nil {
+		return t, err
+	}
+
+	flags := make([]string, len(t.Flags))
+	for i, f := range t.Flags {
+		if flags[i], err = ExecTemplate("flags", f, data); err != nil {
+			return t, err
+		}
+	}
+	t.Flags = flags
+
+	env := make(map[string]string, len(t.Env))
+	for k, v := range t.Env {
+		if env[k], err = ExecTemplate("env "+k, v, data); err != nil {
+			return t, err
+		}
+	}
+	t.Env = env
+	return t, nil
+}
+
+func envMap(env []string) map[string]string {
+	m := make(map[string]string, len(env))
+	for _, e := range env {
+		if i := strings.Index(e, "="); i > 0 {
+			m[e[:i]] = e[i+1:]
+		}
+	}
+	return m
+}
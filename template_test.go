@@ -0,0 +1,60 @@
+package gorelease_test
+
+import (
+	"errors"
+	. "github.com/bukowa/gorelease"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMakeFileBuild_template(t *testing.T) {
+	os.Setenv("GORELEASE_TEST_ENV", "from-env")
+	defer os.Unsetenv("GORELEASE_TEST_ENV")
+
+	target := Target{
+		FilePath: "main.go",
+		Name:     "{{.Os}}-app",
+		Version:  "v1.0.0-{{.ShortCommit}}",
+		DestDir:  "bin/{{.Env.GORELEASE_TEST_ENV}}",
+		Env:      map[string]string{"ARCH": "{{.Arch}}"},
+		Flags:    []string{"-ldflags=-X main.Version={{.Version}} -X main.Commit={{.Commit}} -X main.Date={{.Date}}"},
+		Meta: Meta{
+			Commit: "0123456789abcdef",
+			Date:   time.Date(2020, 9, 14, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	b, err := MakeFileBuild(target, "linux", "arm64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "bin/from-env/v1.0.0-0123456/linux_arm64/linux-app"; b.BinPath != want {
+		t.Errorf("got: %v want: %v", b.BinPath, want)
+	}
+	if b.Name != "linux-app" {
+		t.Errorf("got: %v want: linux-app", b.Name)
+	}
+	wantFlag := "-ldflags=-X main.Version=v1.0.0-0123456 -X main.Commit=0123456789abcdef -X main.Date=2020-09-14T00:00:00Z"
+	if b.Args[1] != wantFlag {
+		t.Errorf("got: %v want: %v", b.Args[1], wantFlag)
+	}
+	if !envMapInSlice(map[string]string{"ARCH": "arm64"}, b.Env) {
+		t.Error("templated env not found")
+	}
+	if target.Flags[0] == wantFlag || !strings.Contains(target.Name, "{{") {
+		t.Error("target was modified")
+	}
+}
+
+func TestMakeFileBuild_templateError(t *testing.T) {
+	target := Target{FilePath: "main.go", Name: "{{.Missing}}", Version: "v1.0.0"}
+	_, err := MakeFileBuild(target, "linux", "amd64")
+	var tmplErr *TemplateError
+	if !errors.As(err, &tmplErr) {
+		t.Fatalf("want TemplateError got: %v", err)
+	}
+	if tmplErr.Field != "name" {
+		t.Errorf("got: %v want: name", tmplErr.Field)
+	}
+}
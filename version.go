@@ -0,0 +1,75 @@
+package gorelease
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"golang.org/x/mod/semver"
+	"os/exec"
+	"strings"
+)
+
+// AutoVersion is a version that is resolved from git tags
+const AutoVersion = "auto"
+
+// VersionError is returned when version is not valid semver
+type VersionError struct {
+	Version string
+}
+
+func (e *VersionError) Error() string {
+	return fmt.Sprintf("version '%s' is not valid semver", e.Version)
+}
+
+var ErrorNoCommits = errors.New("repository has no commits")
+
+// ResolveVersion resolves AutoVersion from git tags,
+// any other version is only validated
+func ResolveVersion(ctx context.Context, version string) (string, error) {
+	var err error
+	if version == AutoVersion {
+		if version, err = GitVersion(ctx); err != nil {
+			return "", err
+		}
+	}
+	if !semver.IsValid(version) {
+		return "", &VersionError{Version: version}
+	}
+	return version, nil
+}
+
+// GitVersion returns tag of HEAD commit, if HEAD is not tagged
+// snapshot version is made from latest tag like `v1.2.3-next+<sha>`
+func GitVersion(ctx context.Context) (string, error) {
+	out, err := runCmdErr(exec.CommandContext(ctx, "git", "rev-parse", "--short", "HEAD"))
+	if err != nil {
+		return "", errors.Wrap(err, ErrorNoCommits.Error())
+	}
+	sha := strings.TrimSpace(string(out))
+
+	// HEAD is tagged
+	out, err = runCmdErr(exec.CommandContext(ctx, "git", "describe", "--tags", "--exact-match", "HEAD"))
+	if err == nil {
+		return strings.TrimSpace(string(out)), nil
+	}
+
+	// latest tag reachable from HEAD
+	latest := "v0.0.0"
+	out, err = runCmdErr(exec.CommandContext(ctx, "git", "describe", "--tags", "--abbrev=0", "HEAD"))
+	if err == nil {
+		latest = strings.TrimSpace(string(out))
+	}
+	return SnapshotVersion(latest, sha), nil
+}
+
+// SnapshotVersion makes version of untagged commit sha after tag
+func SnapshotVersion(tag, sha string) string {
+	if i := strings.Index(tag, "+"); i >= 0 {
+		tag = tag[:i]
+	}
+	sep := "-"
+	if semver.Prerelease(tag) != "" {
+		sep = "."
+	}
+	return tag + sep + "next+" + sha
+}
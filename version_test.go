@@ -0,0 +1,75 @@
+package gorelease_test
+
+import (
+	"context"
+	"errors"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestSnapshotVersion(t *testing.T) {
+	var tests = []struct {
+		tag, want string
+	}{
+		{"v1.2.3", "v1.2.3-next+abc1234"},
+		{"v1.2.3+meta", "v1.2.3-next+abc1234"},
+		{"v1.2.3-rc.1", "v1.2.3-rc.1.next+abc1234"},
+	}
+	for _, tt := range tests {
+		if got := SnapshotVersion(tt.tag, "abc1234"); got != tt.want {
+			t.Errorf("got: %v want: %v", got, tt.want)
+		}
+	}
+}
+
+func TestResolveVersion_invalid(t *testing.T) {
+	_, err := ResolveVersion(context.Background(), "1.2")
+	var verErr *VersionError
+	if !errors.As(err, &verErr) {
+		t.Errorf("want VersionError got: %v", err)
+	}
+}
+
+func TestGitVersion(t *testing.T) {
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "gorelease")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer os.Chdir(wd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	git := func(args ...string) {
+		args = append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("%s: %s", err, out)
+		}
+	}
+	git("init", "-q")
+	git("commit", "-q", "--allow-empty", "-m", "first")
+	git("tag", "v1.2.3")
+
+	ctx := context.Background()
+	if v, err := ResolveVersion(ctx, AutoVersion); err != nil || v != "v1.2.3" {
+		t.Errorf("got: %v %v want: v1.2.3", v, err)
+	}
+
+	git("commit", "-q", "--allow-empty", "-m", "second")
+	v, err := ResolveVersion(ctx, AutoVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(v, "v1.2.3-next+") {
+		t.Errorf("got: %v want snapshot of v1.2.3", v)
+	}
+}
@@ -0,0 +1,261 @@
+package gorelease
+
+import (
+	"context"
+	"fmt"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+	"path"
+	"strings"
+)
+
+// DefaultWingetMessage is a default template of commit message of winget manifests
+const DefaultWingetMessage = "{{.Target}} {{.Version}}"
+
+// WingetManifestVersion is version of schema of written winget manifests
+const WingetManifestVersion = "1.6.0"
+
+// WingetArch maps GOARCH to architecture of winget installer
+var WingetArch = map[string]string{
+	"386":   "x86",
+	"amd64": "x64",
+	"arm64": "arm64",
+}
+
+// Winget configures winget manifests, version, installer and default
+// locale, rendered from release manifest with windows builds of one target
+type Winget struct {
+	Target       string        `yaml:"target"`        // name of target, first target if empty
+	Publisher    string        `yaml:"publisher"`     // like Bukowa
+	Name         string        `yaml:"name"`          // name of package, name of target if empty
+	Identifier   string        `yaml:"identifier"`    // like Publisher.Name if empty
+	Description  string        `yaml:"description"`   // first line is short description
+	Homepage     string        `yaml:"homepage"`      // package url
+	PublisherURL string        `yaml:"publisher_url"` //
+	License      string        `yaml:"license"`       //
+	Bin          []string      `yaml:"bin"`           // templates of executables in archives, executable of target if empty
+	Locale       string        `yaml:"locale"`        // en-US if empty
+	Repository   GitRepository `yaml:"repository"`    // like fork of winget-pkgs, manifests are written into release dir if dir is empty
+}
+
+var (
+	ErrorPublisherNotSet     = errors.New("publisher is not set")
+	ErrorWingetArchiveFormat = errors.New("winget installs only zip archives")
+)
+
+// wingetManifest is common part of winget manifests
+type wingetManifest struct {
+	PackageIdentifier string `yaml:"PackageIdentifier"`
+	PackageVersion    string `yaml:"PackageVersion"`
+}
+
+type wingetVersion struct {
+	wingetManifest  `yaml:",inline"`
+	DefaultLocale   string `yaml:"DefaultLocale"`
+	ManifestType    string `yaml:"ManifestType"`
+	ManifestVersion string `yaml:"ManifestVersion"`
+}
+
+type wingetInstaller struct {
+	wingetManifest  `yaml:",inline"`
+	ReleaseDate     string                 `yaml:"ReleaseDate,omitempty"`
+	Installers      []wingetInstallerEntry `yaml:"Installers"`
+	ManifestType    string                 `yaml:"ManifestType"`
+	ManifestVersion string                 `yaml:"ManifestVersion"`
+}
+
+type wingetInstallerEntry struct {
+	Architecture         string             `yaml:"Architecture"`
+	InstallerType        string             `yaml:"InstallerType"`
+	NestedInstallerType  string             `yaml:"NestedInstallerType,omitempty"`
+	NestedInstallerFiles []wingetNestedFile `yaml:"NestedInstallerFiles,omitempty"`
+	Commands             []string           `yaml:"Commands,omitempty"`
+	InstallerURL         string             `yaml:"InstallerUrl"`
+	InstallerSha256      string             `yaml:"InstallerSha256"`
+}
+
+type wingetNestedFile struct {
+	RelativeFilePath     string `yaml:"RelativeFilePath"`
+	PortableCommandAlias string `yaml:"PortableCommandAlias"`
+}
+
+type wingetLocale struct {
+	wingetManifest   `yaml:",inline"`
+	PackageLocale    string `yaml:"PackageLocale"`
+	Publisher        string `yaml:"Publisher"`
+	PublisherURL     string `yaml:"PublisherUrl,omitempty"`
+	PackageName      string `yaml:"PackageName"`
+	PackageURL       string `yaml:"PackageUrl,omitempty"`
+	License          string `yaml:"License,omitempty"`
+	ShortDescription string `yaml:"ShortDescription,omitempty"`
+	Description      string `yaml:"Description,omitempty"`
+	ManifestType     string `yaml:"ManifestType"`
+	ManifestVersion  string `yaml:"ManifestVersion"`
+}
+
+// WingetRelease is a basic ReleaseFunc writing winget manifests
+var WingetRelease ReleaseFunc = func(release *Release) error {
+	return WingetReleaseContext(context.Background(), release)
+}
+
+// WingetReleaseContext is a basic ReleaseContextFunc writing winget manifests from
+// manifest of published release, they're committed into repository like winget-pkgs
+// if it's configured or written into release dir otherwise
+var WingetReleaseContext ReleaseContextFunc = func(ctx context.Context, release *Release) error {
+	w := release.Winget
+	if w == nil {
+		return nil
+	}
+	m, err := releaseManifest(release)
+	if err != nil {
+		return err
+	}
+	files, err := MakeWingetManifests(release, m)
+	if err != nil {
+		return err
+	}
+	if w.Repository.Dir == "" {
+		for name, b := range files {
+			if err = writeReleaseFile(release, path.Base(name), b); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	message, err := commitMessage(release, w.PackageIdentifier(release), w.Repository.Message, DefaultWingetMessage)
+	if err != nil {
+		return err
+	}
+	return w.Repository.CommitFiles(ctx, files, message)
+}
+
+// PackageIdentifier returns identifier of winget package
+func (w *Winget) PackageIdentifier(r *Release) string {
+	if w.Identifier != "" {
+		return w.Identifier
+	}
+	return strings.Replace(w.Publisher+"."+w.packageName(r), " ", "", -1)
+}
+
+func (w *Winget) packageName(r *Release) string {
+	if w.Name != "" {
+		return w.Name
+	}
+	return targetName(r, w.Target)
+}
+
+// MakeWingetManifests creates version, installer and default locale manifests
+// by their paths in repository like winget-pkgs
+func MakeWingetManifests(r *Release, m *Manifest) (map[string][]byte, error) {
+	w := r.Winget
+	if w.Publisher == "" {
+		return nil, ErrorPublisherNotSet
+	}
+	target, err := r.TargetNamed(w.Target)
+	if err != nil {
+		return nil, err
+	}
+	id := w.PackageIdentifier(r)
+	version := strings.TrimPrefix(m.Version, "v")
+	common := wingetManifest{PackageIdentifier: id, PackageVersion: version}
+	locale := w.Locale
+	if locale == "" {
+		locale = "en-US"
+	}
+
+	installer := wingetInstaller{
+		wingetManifest:  common,
+		ManifestType:    "installer",
+		ManifestVersion: WingetManifestVersion,
+	}
+	if !m.Date.IsZero() {
+		installer.ReleaseDate = m.Date.Format("2006-01-02")
+	}
+	for _, goarch := range []string{"386", "amd64", "arm64"} {
+		a := m.Artifact(target.Name, "windows", goarch)
+		if a == nil {
+			continue
+		}
+		if a.URL == "" {
+			return nil, errors.Errorf("artifact %s has no url", a.Name)
+		}
+		entry := wingetInstallerEntry{
+			Architecture:    WingetArch[goarch],
+			InstallerURL:    a.URL,
+			InstallerSha256: strings.ToUpper(a.SHA256),
+		}
+		if a.Type == ArtifactBinary {
+			entry.InstallerType = "portable"
+			entry.Commands = []string{strings.TrimSuffix(target.Name, ".exe")}
+		} else {
+			if format := archiveFormat(a.Name); format != FormatZip {
+				return nil, errors.Wrapf(ErrorWingetArchiveFormat, "%s is %s", a.Name, format)
+			}
+			entry.InstallerType = FormatZip
+			entry.NestedInstallerType = "portable"
+			data := MakeTemplateData(*target, "windows", goarch)
+			data.Version = m.Version
+			bin := w.Bin
+			if len(bin) == 0 {
+				bin = []string{windowsExe(target.Name)}
+			}
+			for _, tmpl := range bin {
+				exe, err := ExecTemplate("winget.bin", tmpl, data)
+				if err != nil {
+					return nil, err
+				}
+				entry.NestedInstallerFiles = append(entry.NestedInstallerFiles, wingetNestedFile{
+					RelativeFilePath:     exe,
+					PortableCommandAlias: strings.TrimSuffix(path.Base(exe), ".exe"),
+				})
+			}
+		}
+		installer.Installers = append(installer.Installers, entry)
+	}
+	if len(installer.Installers) == 0 {
+		return nil, ErrorNoWindowsBuilds
+	}
+
+	description := strings.TrimSpace(w.Description)
+	manifests := []struct {
+		name, typ string
+		value     interface{}
+	}{
+		{id + ".yaml", "version", wingetVersion{
+			wingetManifest:  common,
+			DefaultLocale:   locale,
+			ManifestType:    "version",
+			ManifestVersion: WingetManifestVersion,
+		}},
+		{id + ".installer.yaml", "installer", installer},
+		{id + ".locale." + locale + ".yaml", "defaultLocale", wingetLocale{
+			wingetManifest:   common,
+			PackageLocale:    locale,
+			Publisher:        w.Publisher,
+			PublisherURL:     w.PublisherURL,
+			PackageName:      w.packageName(r),
+			PackageURL:       w.Homepage,
+			License:          w.License,
+			ShortDescription: strings.SplitN(description, "\n", 2)[0],
+			Description:      description,
+			ManifestType:     "defaultLocale",
+			ManifestVersion:  WingetManifestVersion,
+		}},
+	}
+
+	// like manifests/b/Bukowa/Gorelease/1.2.0 in winget-pkgs
+	dir := path.Join(append([]string{"manifests", strings.ToLower(id[:1])}, strings.Split(id, ".")...)...)
+	dir = path.Join(dir, version)
+	files := map[string][]byte{}
+	for _, manifest := range manifests {
+		b, err := yaml.Marshal(manifest.value)
+		if err != nil {
+			return nil, err
+		}
+		header := fmt.Sprintf("# This file was generated by gorelease. DO NOT EDIT.\n"+
+			"# yaml-language-server: $schema=https://aka.ms/winget-manifest.%s.%s.schema.json\n\n",
+			manifest.typ, WingetManifestVersion)
+		files[path.Join(dir, manifest.name)] = append([]byte(header), b...)
+	}
+	return files, nil
+}
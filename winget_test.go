@@ -0,0 +1,134 @@
+package gorelease_test
+
+import (
+	"errors"
+	. "github.com/bukowa/gorelease"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMakeWingetManifests(t *testing.T) {
+	r := manifestRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	r.Manifest.Date = time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	r.Manifest.Artifacts[6].Type = ArtifactBinary
+	r.Manifest.Artifacts[6].URL = "https://example.com/v1.2.0/windows_amd64/app.exe"
+	r.Winget = &Winget{
+		Publisher:   "Bukowa",
+		Description: "App\nreleases go apps",
+		License:     "MIT",
+	}
+	files, err := MakeWingetManifests(r, r.Manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := "manifests/b/Bukowa/app/1.2.0/"
+	if len(files) != 3 {
+		t.Fatalf("got: %v", files)
+	}
+	if got, want := string(files[dir+"Bukowa.app.yaml"]), `# This file was generated by gorelease. DO NOT EDIT.
+# yaml-language-server: $schema=https://aka.ms/winget-manifest.version.1.6.0.schema.json
+
+PackageIdentifier: Bukowa.app
+PackageVersion: 1.2.0
+DefaultLocale: en-US
+ManifestType: version
+ManifestVersion: 1.6.0
+`; got != want {
+		t.Errorf("got:\n%s", got)
+	}
+	if got, want := string(files[dir+"Bukowa.app.installer.yaml"]), `# This file was generated by gorelease. DO NOT EDIT.
+# yaml-language-server: $schema=https://aka.ms/winget-manifest.installer.1.6.0.schema.json
+
+PackageIdentifier: Bukowa.app
+PackageVersion: 1.2.0
+ReleaseDate: "2021-03-04"
+Installers:
+- Architecture: x86
+  InstallerType: zip
+  NestedInstallerType: portable
+  NestedInstallerFiles:
+  - RelativeFilePath: app.exe
+    PortableCommandAlias: app
+  InstallerUrl: https://example.com/v1.2.0/windows_386/app_v1.2.0_windows_386.zip
+  InstallerSha256: "3333333333333333333333333333333333333333333333333333333333333333"
+- Architecture: x64
+  InstallerType: portable
+  Commands:
+  - app
+  InstallerUrl: https://example.com/v1.2.0/windows_amd64/app.exe
+  InstallerSha256: AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA
+- Architecture: arm64
+  InstallerType: zip
+  NestedInstallerType: portable
+  NestedInstallerFiles:
+  - RelativeFilePath: app.exe
+    PortableCommandAlias: app
+  InstallerUrl: https://example.com/v1.2.0/windows_arm64/app_v1.2.0_windows_arm64.zip
+  InstallerSha256: AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA
+ManifestType: installer
+ManifestVersion: 1.6.0
+`; got != want {
+		t.Errorf("got:\n%s", got)
+	}
+	if got, want := string(files[dir+"Bukowa.app.locale.en-US.yaml"]), `# This file was generated by gorelease. DO NOT EDIT.
+# yaml-language-server: $schema=https://aka.ms/winget-manifest.defaultLocale.1.6.0.schema.json
+
+PackageIdentifier: Bukowa.app
+PackageVersion: 1.2.0
+PackageLocale: en-US
+Publisher: Bukowa
+PackageName: app
+License: MIT
+ShortDescription: App
+Description: |-
+  App
+  releases go apps
+ManifestType: defaultLocale
+ManifestVersion: 1.6.0
+`; got != want {
+		t.Errorf("got:\n%s", got)
+	}
+
+	r.Winget.Publisher = ""
+	if _, err = MakeWingetManifests(r, r.Manifest); err != ErrorPublisherNotSet {
+		t.Errorf("got: %v", err)
+	}
+
+	r.Winget.Publisher = "Bukowa"
+	r.Manifest.Artifacts[5].Name = "windows_386/app_v1.2.0_windows_386.tar.gz"
+	if _, err = MakeWingetManifests(r, r.Manifest); !errors.Is(err, ErrorWingetArchiveFormat) {
+		t.Errorf("got: %v", err)
+	}
+}
+
+func TestWingetRelease(t *testing.T) {
+	r := manifestRelease(t)
+	defer os.RemoveAll(r.DestDir)
+	r.Winget = &Winget{Publisher: "Bukowa", Identifier: "Bukowa.GoRelease"}
+	if err := WingetRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Bukowa.GoRelease.yaml", "Bukowa.GoRelease.installer.yaml", "Bukowa.GoRelease.locale.en-US.yaml"} {
+		if _, err := ioutil.ReadFile(filepath.Join(r.Dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	checkout, bare := tapRepository(t, r.DestDir)
+	r.Winget.Repository = GitRepository{Dir: checkout, Remote: "origin", Branch: "main", Author: "Release Bot <bot@example.com>"}
+	if err := WingetRelease(r); err != nil {
+		t.Fatal(err)
+	}
+	if log := git(t, bare, "log", "--format=%s", "main"); log != "Bukowa.GoRelease v1.2.0\n" {
+		t.Errorf("got: %q", log)
+	}
+	files := git(t, bare, "ls-tree", "-r", "--name-only", "main")
+	if !strings.Contains(files, "manifests/b/Bukowa/GoRelease/1.2.0/Bukowa.GoRelease.installer.yaml\n") {
+		t.Errorf("got: %s", files)
+	}
+}